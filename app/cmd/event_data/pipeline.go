@@ -13,8 +13,10 @@ import (
 	"event-data-pipeline/pkg/processors"
 	"event-data-pipeline/pkg/sources"
 	"event-data-pipeline/pkg/storage_providers"
+	"fmt"
 
 	"sync"
+	"time"
 )
 
 type EventDataPipeline struct { // 이타입으로 생성을 해서 구동을 하는 로직이다.
@@ -123,8 +125,9 @@ func (e *EventDataPipeline) Run() error { // 실제 런을 할 때 필요한 작
 
 		logger.Debugf("%v consumer created", consumer)
 
-		// 컨슈머로 부터 데이터를 받아 처리하는 0개 이상의 프로세서 슬라이스 초기화
-		proccers := make([]processors.Processor, len(cfg.Processors)) //프로세서 생성 시작
+		// 스테이지 러너 슬라이스 초기화
+		stages := make([]pipelines.Stage, len(cfg.Processors)) //프로세서 생성 시작
+		stagePolicies := make([]*pipelines.ErrorPolicy, len(cfg.Processors))
 		for i, p := range cfg.Processors {
 
 			// 부여된 설정값 대로 프로세서 생성
@@ -133,23 +136,43 @@ func (e *EventDataPipeline) Run() error { // 실제 런을 할 때 필요한 작
 			if err != nil {
 				return err
 			}
-			// 스테이지 러너에 생성된 프로세서를 등록
-			proccers[i] = processor
-		}
 
-		//프로세서 생성 끝
+			// 설정 값에 따라 FIFO, WorkerPools 등 처리 방법을 선택
+			// runner 설정이 없으면 FIFO 로 처리
+			runnerCfg := p.Runner
+			if runnerCfg == nil {
+				runnerCfg = &config.RunnerCfg{}
+			}
+			stageRunner, err := pipelines.CreateStageRunner(runnerCfg.Type, processor, runnerCfg.Config)
+			if err != nil {
+				logger.Errorf("%v", err)
+				return err
+			}
+			logger.Debugf("processor[%d]: %v runs on %v", i, p.Name, runnerCfg.Type)
 
-		// 스테이지 러너 슬라이스 초기화
-		stageRunners := make([]pipelines.StageRunner, len(proccers))
+			// 스테이지 러너에 생성된 프로세서를 등록
+			// inputs 가 없으면 바로 앞 스테이지를 입력으로 받는다.
+			stages[i] = pipelines.Stage{
+				Name:   stageName(p, i),
+				Runner: stageRunner,
+				Inputs: p.Inputs,
+			}
 
-		// TODO: 설정 값에 따라 FIFO, WorkerPools 등 처리 방법을 선택
-		for i, p := range proccers {
-			fifo := pipelines.FIFO(p)
-			stageRunners[i] = fifo
+			// 프로세서 에러 처리 정책
+			stagePolicies[i], err = newErrorPolicy(p.OnError, cfg.DeadLetter != nil)
+			if err != nil {
+				logger.Errorf("processor[%d]: %v", i, err)
+				return err
+			}
 		}
 
+		//프로세서 생성 끝
+
 		// 스토리지 프로바이더 생성
 		storageProviders := make([]storage_providers.StorageProvider, len(cfg.Storages))
+		storagePolicies := make([]*pipelines.ErrorPolicy, len(cfg.Storages))
+		routes := make([]*pipelines.Route, len(cfg.Storages))
+		storageInputs := make([][]string, len(cfg.Storages))
 		for i, s := range cfg.Storages {
 			logger.Debugf("storage[%d]: %v", i, s.Type)
 			storageProviders[i], err = storage_providers.CreateStorageProvider(s.Type, s.Config)
@@ -157,10 +180,42 @@ func (e *EventDataPipeline) Run() error { // 실제 런을 할 때 필요한 작
 				logger.Errorf("%v", err)
 				return err
 			}
+			storagePolicies[i], err = newErrorPolicy(s.OnError, cfg.DeadLetter != nil)
+			if err != nil {
+				logger.Errorf("storage[%d]: %v", i, err)
+				return err
+			}
+			storageInputs[i] = s.Inputs
+
+			// 스토리지 라우팅 규칙
+			if r := s.Route; r != nil {
+				routes[i], err = pipelines.NewRoute(r.When, r.Index, r.Topic, r.Queue)
+				if err != nil {
+					logger.Errorf("storage[%d]: %v", i, err)
+					return err
+				}
+			}
 		}
 
 		// 파이프라인 초기화
-		e.p = pipelines.New(stageRunners...)
+		e.p, err = pipelines.NewDAG(stages, storageInputs)
+		if err != nil {
+			logger.Errorf("%v", err)
+			return err
+		}
+		e.p.SetErrorPolicies(stagePolicies, storagePolicies)
+		e.p.SetRoutes(routes)
+
+		// 데드레터 스토리지 생성
+		if cfg.DeadLetter != nil {
+			logger.Debugf("dead letter storage: %v", cfg.DeadLetter.Type)
+			deadLetter, err := storage_providers.CreateStorageProvider(cfg.DeadLetter.Type, cfg.DeadLetter.Config)
+			if err != nil {
+				logger.Errorf("%v", err)
+				return err
+			}
+			e.p.SetDeadLetterSink(deadLetter)
+		}
 
 		// 컨슈머 읽기 고루틴
 		go consumer.Consume(ctx)
@@ -173,6 +228,37 @@ func (e *EventDataPipeline) Run() error { // 실제 런을 할 때 필요한 작
 	return nil
 }
 
+// stageName returns the id of the processor, or its name and position when
+// it has none.
+func stageName(cfg config.ProcessorCfg, idx int) string {
+	if cfg.ID != "" {
+		return cfg.ID
+	}
+	return fmt.Sprintf("%s_%d", cfg.Name, idx)
+}
+
+// newErrorPolicy converts an on_error configuration to a pipelines.ErrorPolicy.
+// A nil configuration returns the default policy.
+func newErrorPolicy(cfg *config.ErrorPolicyCfg, hasDeadLetter bool) (*pipelines.ErrorPolicy, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	policy := &pipelines.ErrorPolicy{
+		Action:      cfg.Policy,
+		MaxAttempts: cfg.MaxAttempts,
+		Backoff:     time.Duration(cfg.BackoffMs) * time.Millisecond,
+		MaxBackoff:  time.Duration(cfg.MaxBackoffMs) * time.Millisecond,
+		Fallback:    cfg.Fallback,
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	if policy.Uses(pipelines.ON_ERROR_DEAD_LETTER) && !hasDeadLetter {
+		return nil, fmt.Errorf("on_error %s requires a dead_letter storage", pipelines.ON_ERROR_DEAD_LETTER)
+	}
+	return policy, nil
+}
+
 func Put(key string, obj jsonObj, data interface{}) {
 	obj[key] = data
 }
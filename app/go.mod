@@ -11,7 +11,9 @@ require (
 	github.com/json-iterator/go v1.1.12
 	github.com/natefinch/lumberjack v2.0.0+incompatible
 	github.com/prometheus/client_golang v1.12.2
+	github.com/santhosh-tekuri/jsonschema/v5 v5.0.0
 	github.com/streadway/amqp v1.0.0
+	go.starlark.net v0.0.0-20211203141949-70c0e40ae128
 	go.uber.org/zap v1.21.0
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c
@@ -36,11 +36,20 @@ type PipelineCfg struct {
 	Consumer   *ConsumerCfg   `json:"consumer,omitempty" yaml:"consumer,omitempty"`
 	Processors []ProcessorCfg `json:"processors,omitempty" yaml:"processors,omitempty"`
 	Storages   []StorageCfg   `json:"storages,omitempty" yaml:"storages,omitempty"`
+	// 처리나 저장에 실패한 페이로드를 저장할 스토리지
+	DeadLetter *StorageCfg `json:"dead_letter,omitempty" yaml:"dead_letter,omitempty"`
 }
 
 type ProcessorCfg struct {
 	Name   string                 `json:"name,omitempty" yaml:"name,omitempty"`
 	Config map[string]interface{} `json:"config,omitempty" yaml:"config,omitempty"`
+	Runner *RunnerCfg             `json:"runner,omitempty" yaml:"runner,omitempty"`
+	// 프로세서 에러 처리 정책, 없으면 halt (데드레터 스토리지가 있으면 dead_letter)
+	OnError *ErrorPolicyCfg `json:"on_error,omitempty" yaml:"on_error,omitempty"`
+	// 다른 스테이지와 스토리지의 inputs 에서 가리키는 스테이지 이름, 없으면 <name>_<순서>
+	ID string `json:"id,omitempty" yaml:"id,omitempty"`
+	// 입력 스테이지의 id 또는 source, 없으면 바로 앞 스테이지 (첫 스테이지는 source)
+	Inputs []string `json:"inputs,omitempty" yaml:"inputs,omitempty"`
 }
 
 // NewConfig creates an instance of Config from command-line args and/or env vars
@@ -8,4 +8,39 @@ type ConsumerCfg struct {
 type StorageCfg struct {
 	Type   string                 `json:"type,omitempty" yaml:"type,omitempty"`
 	Config map[string]interface{} `json:",omitempty" yaml:",omitempty"`
+	// 스토리지 에러 처리 정책, 없으면 halt (데드레터 스토리지가 있으면 dead_letter)
+	OnError *ErrorPolicyCfg `json:"on_error,omitempty" yaml:"on_error,omitempty"`
+	// 스토리지로 보낼 페이로드를 고르는 규칙, 없으면 모든 페이로드를 받는다.
+	Route *RouteCfg `json:"route,omitempty" yaml:"route,omitempty"`
+	// 페이로드를 받을 스테이지의 id, 없으면 마지막 스테이지
+	Inputs []string `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+}
+
+// RouteCfg selects the payloads a storage receives. Every rule that is set
+// must match: the When expression and one of the listed indexes, topics and
+// queues.
+type RouteCfg struct {
+	When  string   `json:"when,omitempty" yaml:"when,omitempty"`
+	Index []string `json:"index,omitempty" yaml:"index,omitempty"`
+	Topic []string `json:"topic,omitempty" yaml:"topic,omitempty"`
+	Queue []string `json:"queue,omitempty" yaml:"queue,omitempty"`
+}
+
+// ErrorPolicyCfg decides what happens to payloads that fail a processor or a
+// storage: halt, skip, nack, retry or dead_letter. Retries back off exponentially
+// from BackoffMs up to MaxBackoffMs and follow Fallback once MaxAttempts is
+// reached.
+type ErrorPolicyCfg struct {
+	Policy       string `json:"policy,omitempty" yaml:"policy,omitempty"`
+	MaxAttempts  int    `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`
+	BackoffMs    int    `json:"backoff_ms,omitempty" yaml:"backoff_ms,omitempty"`
+	MaxBackoffMs int    `json:"max_backoff_ms,omitempty" yaml:"max_backoff_ms,omitempty"`
+	Fallback     string `json:"fallback,omitempty" yaml:"fallback,omitempty"`
+}
+
+// RunnerCfg selects the StageRunner that executes a processor, e.g. fifo,
+// fixed_pool or dynamic_pool. An empty Type falls back to fifo.
+type RunnerCfg struct {
+	Type   string                 `json:"type,omitempty" yaml:"type,omitempty"`
+	Config map[string]interface{} `json:"config,omitempty" yaml:"config,omitempty"`
 }
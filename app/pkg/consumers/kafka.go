@@ -19,9 +19,33 @@ func init() { // init 함수를 통해서 메인 메소드를 구동하기 전
 }
 
 type KafkaClientConfig struct {
-	ClientName      string  `json:"client_name,omitempty"`
-	Topic           string  `json:"topic,omitempty"`
-	ConsumerOptions jsonObj `json:"consumer_options,omitempty"`
+	ClientName string `json:"client_name,omitempty"`
+	Topic      string `json:"topic,omitempty"`
+	// 여러 토픽을 읽을 때 사용, ^ 로 시작하면 정규표현식 (예: ^purchases\..*)
+	Topics          []string `json:"topics,omitempty"`
+	ConsumerOptions jsonObj  `json:"consumer_options,omitempty"`
+	// enable.auto.commit 이 "false" 일 때 처리된 오프셋을 커밋하는 주기
+	CommitIntervalMs int `json:"commit_interval_ms,omitempty"`
+	// true 이면 파티션을 직접 할당하지 않고 컨슈머 그룹으로 토픽을 구독
+	GroupMode bool `json:"group_mode,omitempty"`
+	// 파티션이 회수될 때 처리 중인 페이로드를 기다리는 최대 시간
+	RebalanceTimeoutMs int `json:"rebalance_timeout_ms,omitempty"`
+	// 새로운 토픽과 파티션을 찾기 위해 메타데이터를 다시 읽는 주기, 음수이면 사용하지 않음
+	MetadataRefreshIntervalMs int `json:"metadata_refresh_interval_ms,omitempty"`
+}
+
+// AllTopics returns topic and topics without duplicates.
+func (c KafkaClientConfig) AllTopics() []string {
+	var topics []string
+	seen := make(map[string]bool)
+	for _, topic := range append([]string{c.Topic}, c.Topics...) {
+		if topic == "" || seen[topic] {
+			continue
+		}
+		seen[topic] = true
+		topics = append(topics, topic)
+	}
+	return topics
 }
 
 // Consumer interface 구현체
@@ -47,8 +71,12 @@ func NewKafkaConsumerClient(config jsonObj) Consumer { //Consumer 타입을 반
 	json.Unmarshal(cfgData, &kcCfg)
 
 	kfkCnsmrCfg := make(jsonObj)
-	kfkCnsmrCfg["topic"] = kcCfg.Topic
+	kfkCnsmrCfg["topics"] = kcCfg.AllTopics()
 	kfkCnsmrCfg["consumerOptions"] = kcCfg.ConsumerOptions
+	kfkCnsmrCfg["commitIntervalMs"] = kcCfg.CommitIntervalMs
+	kfkCnsmrCfg["groupMode"] = kcCfg.GroupMode
+	kfkCnsmrCfg["rebalanceTimeoutMs"] = kcCfg.RebalanceTimeoutMs
+	kfkCnsmrCfg["metadataRefreshIntervalMs"] = kcCfg.MetadataRefreshIntervalMs
 	kfkCnsmrCfg["pipeParams"] = config["pipeParams"]
 	kfkCnsmr := kafka.NewKafkaConsumer(kfkCnsmrCfg)
 
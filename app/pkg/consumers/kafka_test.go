@@ -9,6 +9,7 @@ import (
 	"event-data-pipeline/pkg/logger"
 	"os"
 	"path"
+	"reflect"
 	"runtime"
 	"testing"
 	"time"
@@ -84,3 +85,26 @@ func getCurDir() string {
 	}
 	return dir
 }
+
+func TestKafkaClientConfig_AllTopics(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  consumers.KafkaClientConfig
+		want []string
+	}{
+		{"topic only", consumers.KafkaClientConfig{Topic: "purchases"}, []string{"purchases"}},
+		{"topics only", consumers.KafkaClientConfig{Topics: []string{"purchases", "refunds"}}, []string{"purchases", "refunds"}},
+		{"topic first", consumers.KafkaClientConfig{Topic: "refunds", Topics: []string{"purchases"}}, []string{"refunds", "purchases"}},
+		{"duplicates", consumers.KafkaClientConfig{Topic: "purchases", Topics: []string{"purchases", "refunds", "refunds"}}, []string{"purchases", "refunds"}},
+		{"empty names", consumers.KafkaClientConfig{Topics: []string{"", "purchases", ""}}, []string{"purchases"}},
+		{"patterns", consumers.KafkaClientConfig{Topic: `^purchases\..*`, Topics: []string{`^purchases\..*`, "refunds"}}, []string{`^purchases\..*`, "refunds"}},
+		{"none", consumers.KafkaClientConfig{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.AllTopics(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
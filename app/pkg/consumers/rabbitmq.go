@@ -33,14 +33,11 @@ func NewRabbitMQConsumerClient(config jsonObj) Consumer {
 
 // Init implements Consumer
 func (rc *RabbitMQConsumerClient) Init() error {
+	// 연결, 채널, 익스체인지와 큐 선언, 바인딩, prefetch 설정
 	err := rc.CreateConsumer()
 	if err != nil {
 		return err
 	}
-	err = rc.QueueDeclare()
-	if err != nil {
-		return err
-	}
 
 	err = rc.InitDeliveryChannel()
 	if err != nil {
@@ -0,0 +1,71 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+type builtin struct {
+	arity int
+	call  func(args []interface{}) (interface{}, error)
+}
+
+// 표현식에서 사용할 수 있는 함수
+var builtins = map[string]builtin{
+	// has(field): 필드가 있으면 true, 값이 null 이어도 true
+	"has": {arity: 1},
+	"len": {arity: 1, call: func(args []interface{}) (interface{}, error) {
+		switch v := args[0].(type) {
+		case nil:
+			return float64(0), nil
+		case string:
+			return float64(utf8.RuneCountInString(v)), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		case map[string]interface{}:
+			return float64(len(v)), nil
+		}
+		return nil, fmt.Errorf("unexpected %s", typeName(args[0]))
+	}},
+	"lower":       stringFunc(strings.ToLower),
+	"upper":       stringFunc(strings.ToUpper),
+	"contains":    stringPredicate(strings.Contains),
+	"starts_with": stringPredicate(strings.HasPrefix),
+	"ends_with":   stringPredicate(strings.HasSuffix),
+	"matches": {arity: 2, call: func(args []interface{}) (interface{}, error) {
+		pattern, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("expects a string pattern, got %s", typeName(args[1]))
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := args[0].(string)
+		return ok && re.MatchString(s), nil
+	}},
+}
+
+func stringFunc(fn func(string) string) builtin {
+	return builtin{arity: 1, call: func(args []interface{}) (interface{}, error) {
+		switch v := args[0].(type) {
+		case nil:
+			return nil, nil
+		case string:
+			return fn(v), nil
+		}
+		return nil, fmt.Errorf("expects a string, got %s", typeName(args[0]))
+	}}
+}
+
+// stringPredicate returns false when either argument is not a string, so
+// predicates on missing fields are false.
+func stringPredicate(fn func(s, arg string) bool) builtin {
+	return builtin{arity: 2, call: func(args []interface{}) (interface{}, error) {
+		s, ok := args[0].(string)
+		arg, argOK := args[1].(string)
+		return ok && argOK && fn(s, arg), nil
+	}}
+}
@@ -0,0 +1,352 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// node 는 구문 트리의 노드이다.
+type node interface {
+	eval(env map[string]interface{}) (interface{}, error)
+}
+
+type literal struct {
+	v interface{}
+}
+
+func (l *literal) eval(env map[string]interface{}) (interface{}, error) {
+	return l.v, nil
+}
+
+type array struct {
+	elems []node
+}
+
+func (a *array) eval(env map[string]interface{}) (interface{}, error) {
+	arr := make([]interface{}, len(a.elems))
+	for i, elem := range a.elems {
+		v, err := elem.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = v
+	}
+	return arr, nil
+}
+
+// field 는 환경에서 name 을 찾은 뒤 accessors 를 차례로 따라간다.
+type field struct {
+	name      string
+	accessors []node
+}
+
+func (f *field) eval(env map[string]interface{}) (interface{}, error) {
+	v, _, err := f.lookup(env)
+	return v, err
+}
+
+// lookup returns the value of the field and whether it exists.
+func (f *field) lookup(env map[string]interface{}) (interface{}, bool, error) {
+	cur, ok := env[f.name]
+	for _, accessor := range f.accessors {
+		if !ok {
+			return nil, false, nil
+		}
+		key, err := accessor.eval(env)
+		if err != nil {
+			return nil, false, err
+		}
+		switch k := normalize(key).(type) {
+		case string:
+			switch obj := cur.(type) {
+			case map[string]interface{}:
+				cur, ok = obj[k]
+			case map[string]string:
+				cur, ok = obj[k]
+			default:
+				ok = false
+			}
+		case float64:
+			arr, isArr := cur.([]interface{})
+			i := int(k)
+			if i < 0 {
+				i += len(arr)
+			}
+			ok = isArr && i >= 0 && i < len(arr)
+			if ok {
+				cur = arr[i]
+			}
+		default:
+			return nil, false, fmt.Errorf("invalid index %v", key)
+		}
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	return normalize(cur), true, nil
+}
+
+type unary struct {
+	not bool
+	x   node
+}
+
+func (u *unary) eval(env map[string]interface{}) (interface{}, error) {
+	v, err := u.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if u.not {
+		return !Truthy(v), nil
+	}
+	n, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("cannot negate %s", typeName(v))
+	}
+	return -n, nil
+}
+
+// logical 은 왼쪽 피연산자로 결과가 정해지면 오른쪽을 평가하지 않는다.
+type logical struct {
+	and         bool
+	left, right node
+}
+
+func (l *logical) eval(env map[string]interface{}) (interface{}, error) {
+	v, err := l.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if Truthy(v) != l.and {
+		return Truthy(v), nil
+	}
+	v, err = l.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return Truthy(v), nil
+}
+
+type binary struct {
+	op          string
+	left, right node
+	// =~ 의 오른쪽이 리터럴일 때 미리 컴파일한 정규식
+	re *regexp.Regexp
+}
+
+func (b *binary) eval(env map[string]interface{}) (interface{}, error) {
+	l, err := b.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	r, err := b.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch b.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "<", "<=", ">", ">=":
+		return compare(b.op, l, r)
+	case "=~":
+		return b.match(l, r)
+	case "in":
+		return contains(r, l)
+	}
+	return arithmetic(b.op, l, r)
+}
+
+func (b *binary) match(l, r interface{}) (interface{}, error) {
+	s, ok := l.(string)
+	if !ok {
+		return false, nil
+	}
+	re := b.re
+	if re == nil {
+		pattern, ok := r.(string)
+		if !ok {
+			return nil, fmt.Errorf("=~ expects a string pattern, got %s", typeName(r))
+		}
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return nil, err
+		}
+	}
+	return re.MatchString(s), nil
+}
+
+type call struct {
+	name string
+	fn   builtin
+	args []node
+}
+
+func (c *call) eval(env map[string]interface{}) (interface{}, error) {
+	// has 는 값이 아니라 필드가 있는지를 확인한다.
+	if c.name == "has" {
+		_, ok, err := c.args[0].(*field).lookup(env)
+		return ok, err
+	}
+	args := make([]interface{}, len(c.args))
+	for i, arg := range c.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	v, err := c.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.name, err)
+	}
+	return v, nil
+}
+
+// equal compares values of any type. Numbers compare by value, objects and
+// arrays deeply.
+func equal(l, r interface{}) bool {
+	switch lv := l.(type) {
+	case nil, bool, float64, string:
+		return l == r
+	default:
+		return reflect.DeepEqual(lv, r)
+	}
+}
+
+// compare orders two numbers or two strings. Null is neither less nor
+// greater than anything, so comparisons with missing fields are false.
+func compare(op string, l, r interface{}) (interface{}, error) {
+	if l == nil || r == nil {
+		return false, nil
+	}
+	var c int
+	switch lv := l.(type) {
+	case float64:
+		rv, ok := r.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare number with %s", typeName(r))
+		}
+		c = compareFloat(lv, rv)
+	case string:
+		rv, ok := r.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare string with %s", typeName(r))
+		}
+		c = strings.Compare(lv, rv)
+	default:
+		return nil, fmt.Errorf("cannot compare %s", typeName(l))
+	}
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+func compareFloat(l, r float64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
+
+// contains reports whether the array holds the value or the object has the
+// key.
+func contains(container, v interface{}) (interface{}, error) {
+	switch c := container.(type) {
+	case []interface{}:
+		for _, item := range c {
+			if equal(v, normalize(item)) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		k, ok := v.(string)
+		if !ok {
+			return false, nil
+		}
+		_, ok = c[k]
+		return ok, nil
+	case nil:
+		return false, nil
+	}
+	return nil, fmt.Errorf("in expects an array or an object, got %s", typeName(container))
+}
+
+func arithmetic(op string, l, r interface{}) (interface{}, error) {
+	if op == "+" {
+		if ls, ok := l.(string); ok {
+			if rs, ok := r.(string); ok {
+				return ls + rs, nil
+			}
+		}
+	}
+	ln, lok := l.(float64)
+	rn, rok := r.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("cannot apply %s to %s and %s", op, typeName(l), typeName(r))
+	}
+	switch op {
+	case "+":
+		return ln + rn, nil
+	case "-":
+		return ln - rn, nil
+	case "*":
+		return ln * rn, nil
+	case "/":
+		if rn == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return ln / rn, nil
+	}
+	if rn == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	return math.Mod(ln, rn), nil
+}
+
+// normalize converts go number types to float64 like decoded json numbers.
+func normalize(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	}
+	return v
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
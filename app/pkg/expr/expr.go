@@ -0,0 +1,110 @@
+// Package expr evaluates small boolean expressions against json objects, such
+// as `value.amount > 100 && key != ""`.
+//
+// Identifiers look fields up in the environment, with `.name` or `['name']`
+// for members and `[0]` for array elements. Missing fields evaluate to null.
+// The language has number, string, bool and null literals, array literals,
+// the operators
+//
+//	||  &&  !  ==  !=  <  <=  >  >=  =~  in  +  -  *  /  %
+//
+// and the functions has, len, lower, upper, contains, starts_with, ends_with
+// and matches. `&&`, `||` and `!` work on the truthiness of their operands,
+// and `and`, `or` and `not` are aliases of them.
+//
+// Operators bind from loosest to tightest: `||`, `&&`, the comparisons, which
+// do not chain, `+ -`, `* / %` and the unary `!` and `-`. As in Go, `!` binds
+// tighter than comparisons, so `!a == b` is `(!a) == b`. `&&` and `||` do
+// not evaluate their right operand once the left one decides the result.
+//
+// Arithmetic needs two numbers, or two strings for `+`, and ordering two
+// numbers or two strings. Other operands are evaluation errors, except that
+// ordering with null, as with a missing field, is false.
+//
+// The language is small on purpose instead of relying on a general purpose
+// expression library. Filters and routes evaluate it on every payload, so it
+// only reads fields and compares them: there are no loops, variables or
+// user functions, and an evaluation is linear in the size of the expression.
+// Fields are read from the values payloads already hold, such as
+// map[string]string headers or int32 partitions, without converting them
+// first. Logic beyond that belongs in the script processor.
+package expr
+
+import (
+	"fmt"
+)
+
+// Expr is a compiled expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Compile parses an expression.
+func Compile(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, fmt.Errorf("expr %q: %w", src, err)
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = fmt.Errorf("unexpected %s at %d", p.peek(), p.peek().pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expr %q: %w", src, err)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// MustCompile is like Compile but panics if the expression cannot be parsed.
+func MustCompile(src string) *Expr {
+	e, err := Compile(src)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// Eval evaluates the expression against the environment.
+func (e *Expr) Eval(env map[string]interface{}) (interface{}, error) {
+	v, err := e.root.eval(env)
+	if err != nil {
+		return nil, fmt.Errorf("expr %q: %w", e.src, err)
+	}
+	return v, nil
+}
+
+// Match evaluates the expression and reports whether the result is truthy.
+func (e *Expr) Match(env map[string]interface{}) (bool, error) {
+	v, err := e.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	return Truthy(v), nil
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Truthy reports whether a value counts as true: null, false, 0, empty
+// strings, arrays and objects are false, everything else is true.
+func Truthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case float64:
+		return val != 0
+	case string:
+		return val != ""
+	case []interface{}:
+		return len(val) > 0
+	case map[string]interface{}:
+		return len(val) > 0
+	}
+	return true
+}
@@ -0,0 +1,245 @@
+package expr_test
+
+import (
+	"event-data-pipeline/pkg/expr"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpr_Eval(t *testing.T) {
+	env := map[string]interface{}{
+		"key":     "user-1",
+		"headers": map[string]string{"type": "purchase"},
+		"source":  map[string]interface{}{"topic": "purchases", "partition": int32(2)},
+		"value": map[string]interface{}{
+			"amount": float64(150),
+			"email":  "Jane@Example.com",
+			"tags":   []interface{}{"vip", "new"},
+			"user":   map[string]interface{}{"first-name": "jane", "age": nil},
+		},
+	}
+	testCases := []struct {
+		src  string
+		want interface{}
+	}{
+		{`value.amount > 100 && key != ""`, true},
+		{`value.amount > 100 and key == ""`, false},
+		{`value.amount >= 150 || missing.field`, true},
+		{`!(value.amount < 100)`, true},
+		{`not value.missing`, true},
+		{`value.missing > 1`, false},
+		{`value.missing == null`, true},
+		{`value.amount * 2 - 50 == 250`, true},
+		{`value.amount % 7`, float64(3)},
+		{`-value.amount`, float64(-150)},
+		{`source.partition == 2`, true},
+		{`headers.type == "purchase"`, true},
+		{`value.user['first-name'] == 'jane'`, true},
+		{`value.tags[0] == "vip" && value.tags[-1] == "new"`, true},
+		{`"vip" in value.tags`, true},
+		{`"user" in value`, true},
+		{`source.topic in ["orders", "purchases"]`, true},
+		{`value.email =~ "@example\\.com$"`, false},
+		{`lower(value.email) =~ "@example\\.com$"`, true},
+		{`matches(value.email, "^[A-Z]")`, true},
+		{`has(value.user.age) && !has(value.user.name)`, true},
+		{`len(value.tags) == 2 && len("한글") == 2`, true},
+		{`starts_with(key, "user-") && ends_with(source.topic, "s") && contains(key, "-")`, true},
+		{`upper(value.missing)`, nil},
+		{`"a" + "b"`, "ab"},
+		{`1e3 == 1000`, true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.src, func(t *testing.T) {
+			got, err := expr.MustCompile(tC.src).Eval(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tC.want) {
+				t.Errorf("expected %v, got %v", tC.want, got)
+			}
+		})
+	}
+}
+
+func TestExpr_Precedence(t *testing.T) {
+	testCases := []struct {
+		src  string
+		want interface{}
+	}{
+		{`1 + 2 * 3`, float64(7)},
+		{`(1 + 2) * 3`, float64(9)},
+		{`10 - 4 - 3`, float64(3)},
+		{`24 / 4 / 2`, float64(3)},
+		{`7 % 4 * 2`, float64(6)},
+		{`-2 * 3`, float64(-6)},
+		{`- -2`, float64(2)},
+		{`2 * 3 == 6`, true},
+		{`"a" + "b" in ["ab"]`, true},
+		{`1 + 1 == 2 && 2 > 1`, true},
+		{`true || false && false`, true},
+		{`(true || false) && false`, false},
+		{`false && true || true`, true},
+		{`true or false and false`, true},
+		{`!false && false`, false},
+		{`!(false && false)`, true},
+		// ! 은 비교 연산자보다 먼저 적용된다: (!1) == false
+		{`!1 == false`, true},
+		{`not 1 == 2`, false},
+		{`!!"a"`, true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.src, func(t *testing.T) {
+			got, err := expr.MustCompile(tC.src).Eval(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tC.want) {
+				t.Errorf("expected %v, got %v", tC.want, got)
+			}
+		})
+	}
+}
+
+func TestExpr_ShortCircuit(t *testing.T) {
+	env := map[string]interface{}{"value": map[string]interface{}{"amount": float64(1), "name": "a"}}
+	// 오른쪽 피연산자는 평가되면 에러가 난다.
+	testCases := []struct {
+		src  string
+		want interface{}
+	}{
+		{`true || 1 / 0`, true},
+		{`false && 1 / 0`, false},
+		{`value.amount > 0 or value.name - 1`, true},
+		{`value.missing and value.name < 1`, false},
+		{`has(value.user) && value.user.age + 1 > 0`, false},
+		{`value.amount == 1 || value.amount == 2 && 1 / 0`, true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.src, func(t *testing.T) {
+			got, err := expr.MustCompile(tC.src).Eval(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tC.want) {
+				t.Errorf("expected %v, got %v", tC.want, got)
+			}
+		})
+	}
+
+	// 왼쪽으로 결과가 정해지지 않으면 오른쪽의 에러를 돌려준다.
+	for _, src := range []string{`false || 1 / 0`, `true && 1 / 0`, `1 / 0 || true`} {
+		if _, err := expr.MustCompile(src).Eval(env); err == nil {
+			t.Errorf("expected an error evaluating %s", src)
+		}
+	}
+}
+
+func TestExpr_Types(t *testing.T) {
+	env := map[string]interface{}{
+		"headers": map[string]string{"type": "purchase"},
+		"value":   map[string]interface{}{"amount": float64(1), "count": int64(1), "name": "a", "flag": true},
+	}
+	// 타입이 달라도 정의된 결과가 있는 연산
+	testCases := []struct {
+		src  string
+		want interface{}
+	}{
+		{`1 == "1"`, false},
+		{`value.amount == value.count`, true},
+		{`null == false`, false},
+		{`[1, "a"] == [1, "a"]`, true},
+		{`value.missing < 1`, false},
+		{`1 >= value.missing`, false},
+		{`value.amount =~ "1"`, false},
+		{`starts_with(value.amount, "1")`, false},
+		{`1 in value`, false},
+		{`"a" in value.missing`, false},
+		{`headers.type in ["purchase"]`, true},
+		{`len(value.missing)`, float64(0)},
+		{`lower(value.missing)`, nil},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.src, func(t *testing.T) {
+			got, err := expr.MustCompile(tC.src).Eval(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tC.want) {
+				t.Errorf("expected %v, got %v", tC.want, got)
+			}
+		})
+	}
+
+	// 타입 에러
+	errorCases := []struct {
+		src string
+		err string
+	}{
+		{`"a" < 1`, "cannot compare string with number"},
+		{`value.amount > "1"`, "cannot compare number with string"},
+		{`value.flag < true`, "cannot compare bool"},
+		{`[1] < [2]`, "cannot compare array"},
+		{`"a" - "b"`, "cannot apply - to string and string"},
+		{`"a" * 2`, "cannot apply * to string and number"},
+		{`1 + "a"`, "cannot apply + to number and string"},
+		{`value.flag + 1`, "cannot apply + to bool and number"},
+		{`value.missing + 1`, "cannot apply + to null and number"},
+		{`-value.name`, "cannot negate string"},
+		{`-true`, "cannot negate bool"},
+		{`1 in "abc"`, "in expects an array or an object, got string"},
+		{`1 in value.amount`, "in expects an array or an object, got number"},
+		{`value.name =~ value.amount`, "=~ expects a string pattern, got number"},
+		{`len(1)`, "len: unexpected number"},
+		{`upper(value.flag)`, "upper: expects a string, got bool"},
+		{`matches(value.name, 1)`, "matches: expects a string pattern, got number"},
+		{`value[true]`, "invalid index true"},
+	}
+	for _, tC := range errorCases {
+		t.Run(tC.src, func(t *testing.T) {
+			_, err := expr.MustCompile(tC.src).Eval(env)
+			if err == nil || !strings.Contains(err.Error(), tC.err) {
+				t.Errorf("expected an error containing %q, got %v", tC.err, err)
+			}
+		})
+	}
+}
+
+func TestExpr_EvalError(t *testing.T) {
+	env := map[string]interface{}{"value": map[string]interface{}{"amount": float64(1), "name": "a"}}
+	for _, src := range []string{
+		`value.amount > "1"`,
+		`value.name - 1`,
+		`value.amount / 0`,
+		`"a" in value.amount`,
+		`matches(value.name, "[")`,
+	} {
+		if _, err := expr.MustCompile(src).Eval(env); err == nil {
+			t.Errorf("expected an error evaluating %s", src)
+		}
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`value.amount >`,
+		`(value.amount > 1`,
+		`value. > 1`,
+		`"unterminated`,
+		`unknown(value)`,
+		`len(value, key)`,
+		`has("key")`,
+		`value =~ "["`,
+		`value.amount > 1 value`,
+		`value # 1`,
+		`1 < 2 < 3`,
+		`1 == 1 == true`,
+		`"a" =~ 1`,
+	} {
+		if _, err := expr.Compile(src); err == nil {
+			t.Errorf("expected an error compiling %q", src)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// 길이가 긴 연산자부터 비교한다.
+var operators = []string{
+	"&&", "||", "==", "!=", "<=", ">=", "=~",
+	"(", ")", "[", "]", ",", ".", "!", "<", ">", "+", "-", "*", "/", "%",
+}
+
+// lex splits the source into tokens.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.' || src[i] == 'e' || src[i] == 'E' ||
+				((src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E'))) {
+				i++
+			}
+			n, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at %d", src[start:i], start)
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], num: n, pos: start})
+		case c == '"' || c == '\'':
+			s, n, err := lexString(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%v at %d", err, i)
+			}
+			tokens = append(tokens, token{kind: tokString, text: s, pos: i})
+			i += n
+		case isLetter(src[i]):
+			start := i
+			for i < len(src) && (isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// lexString reads a quoted string and returns it with the number of bytes
+// read. Single-quoted strings support the same escapes as double-quoted ones.
+func lexString(src string) (string, int, error) {
+	quote := src[0]
+	for i := 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case quote:
+			body := src[1:i]
+			if quote == '\'' {
+				body = strings.ReplaceAll(strings.ReplaceAll(body, `\'`, `'`), `"`, `\"`)
+			}
+			s, err := strconv.Unquote(`"` + body + `"`)
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid string %s", src[:i+1])
+			}
+			return s, i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
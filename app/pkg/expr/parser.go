@@ -0,0 +1,249 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// parser 는 연산자 우선순위에 따라 재귀 하강으로 구문 트리를 만든다.
+//
+//	or         = and { "||" and }
+//	and        = comparison { "&&" comparison }
+//	comparison = additive [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "=~" | "in" ) additive ]
+//	additive   = multiplicative { ( "+" | "-" ) multiplicative }
+//	multiplicative = unary { ( "*" | "/" | "%" ) unary }
+//	unary      = ( "!" | "-" ) unary | primary
+//	primary    = literal | array | call | field | "(" or ")"
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of the operators or keywords.
+func (p *parser) accept(texts ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokOp && t.kind != tokIdent {
+		return "", false
+	}
+	for _, text := range texts {
+		if t.text == text {
+			p.pos++
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		return fmt.Errorf("expected %q, got %s at %d", text, p.peek(), p.peek().pos)
+	}
+	return nil
+}
+
+func (p *parser) parseExpr() (node, error) {
+	return p.parseLogical(0)
+}
+
+// 낮은 우선순위부터 나열한 논리 연산자
+var logicalOps = [][]string{{"||", "or"}, {"&&", "and"}}
+
+func (p *parser) parseLogical(level int) (node, error) {
+	if level == len(logicalOps) {
+		return p.parseComparison()
+	}
+	left, err := p.parseLogical(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(logicalOps[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseLogical(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{and: op == "&&" || op == "and", left: left, right: right}
+	}
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseArithmetic(0)
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<", "<=", ">", ">=", "=~", "in")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseArithmetic(0)
+	if err != nil {
+		return nil, err
+	}
+	b := &binary{op: op, left: left, right: right}
+	// 정규식이 리터럴이면 미리 컴파일한다.
+	if lit, ok := right.(*literal); ok && op == "=~" {
+		s, ok := lit.v.(string)
+		if !ok {
+			return nil, fmt.Errorf("=~ expects a string pattern")
+		}
+		if b.re, err = regexp.Compile(s); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// 낮은 우선순위부터 나열한 산술 연산자
+var arithmeticOps = [][]string{{"+", "-"}, {"*", "/", "%"}}
+
+func (p *parser) parseArithmetic(level int) (node, error) {
+	if level == len(arithmeticOps) {
+		return p.parseUnary()
+	}
+	left, err := p.parseArithmetic(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(arithmeticOps[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseArithmetic(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if op, ok := p.accept("!", "not", "-"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{not: op != "-", x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &literal{v: t.num}, nil
+	case tokString:
+		return &literal{v: t.text}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literal{v: true}, nil
+		case "false":
+			return &literal{v: false}, nil
+		case "null", "nil":
+			return &literal{v: nil}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(t)
+		}
+		return p.parseField(t.text)
+	case tokOp:
+		switch t.text {
+		case "(":
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		case "[":
+			elems, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &array{elems: elems}, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %s at %d", t, t.pos)
+}
+
+// parseList parses comma separated expressions up to the closing token.
+func (p *parser) parseList(closing string) ([]node, error) {
+	var elems []node
+	if _, ok := p.accept(closing); ok {
+		return elems, nil
+	}
+	for {
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, x)
+		if _, ok := p.accept(","); !ok {
+			return elems, p.expect(closing)
+		}
+	}
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := builtins[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s at %d", name.text, name.pos)
+	}
+	args, err := p.parseList(")")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != fn.arity {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", name.text, fn.arity, len(args))
+	}
+	c := &call{name: name.text, fn: fn, args: args}
+	// has 는 필드가 있는지를 확인하므로 인자로 필드만 받는다.
+	if name.text == "has" {
+		if _, ok := args[0].(*field); !ok {
+			return nil, fmt.Errorf("has expects a field")
+		}
+	}
+	return c, nil
+}
+
+func (p *parser) parseField(name string) (node, error) {
+	f := &field{name: name}
+	for {
+		if _, ok := p.accept("."); ok {
+			t := p.next()
+			if t.kind != tokIdent {
+				return nil, fmt.Errorf("expected a member name, got %s at %d", t, t.pos)
+			}
+			f.accessors = append(f.accessors, &literal{v: t.text})
+			continue
+		}
+		if _, ok := p.accept("["); ok {
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			f.accessors = append(f.accessors, x)
+			continue
+		}
+		return f, nil
+	}
+}
@@ -0,0 +1,220 @@
+// Package jsonpath evaluates a small subset of JSONPath against decoded json
+// values: the root `$`, child members `.name` or `['name']` and array indexes
+// `[0]`. Negative indexes count from the end of the array.
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 경로의 시작을 나타내는 루트 기호
+const ROOT = "$"
+
+// 점 표기법으로 쓸 수 있는 멤버 이름
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Path is a parsed JSONPath expression.
+type Path []segment
+
+// segment 는 오브젝트의 멤버 이름이나 배열의 인덱스 중 하나이다.
+type segment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// IsPath reports whether the expression is a JSONPath rather than a literal or
+// a template.
+func IsPath(expr string) bool {
+	return strings.HasPrefix(strings.TrimSpace(expr), ROOT)
+}
+
+// Parse parses a JSONPath expression such as `$.value.items[0]['first-name']`.
+func Parse(expr string) (Path, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, ROOT) {
+		return nil, fmt.Errorf("jsonpath %q: must start with %s", expr, ROOT)
+	}
+	var path Path
+	rest := expr[len(ROOT):]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("jsonpath %q: empty member name", expr)
+			}
+			path = append(path, segment{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath %q: missing ]", expr)
+			}
+			seg, err := parseBracket(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath %q: %w", expr, err)
+			}
+			path = append(path, seg)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("jsonpath %q: unexpected %q", expr, rest[0])
+		}
+	}
+	return path, nil
+}
+
+// MustParse is like Parse but panics if the expression cannot be parsed.
+func MustParse(expr string) Path {
+	path, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
+
+func parseBracket(s string) (segment, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return segment{key: s[1 : len(s)-1]}, nil
+	}
+	index, err := strconv.Atoi(s)
+	if err != nil {
+		return segment{}, fmt.Errorf("invalid index %q", s)
+	}
+	return segment{index: index, isIndex: true}, nil
+}
+
+// Get returns the value the path points to in obj, and whether it exists.
+func (p Path) Get(obj interface{}) (interface{}, bool) {
+	cur := obj
+	for _, seg := range p {
+		var ok bool
+		if cur, ok = seg.get(cur); !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func (s segment) get(v interface{}) (interface{}, bool) {
+	if s.isIndex {
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		i := s.index
+		if i < 0 {
+			i += len(arr)
+		}
+		if i < 0 || i >= len(arr) {
+			return nil, false
+		}
+		return arr[i], true
+	}
+	switch obj := v.(type) {
+	case map[string]interface{}:
+		val, ok := obj[s.key]
+		return val, ok
+	case map[string]string:
+		val, ok := obj[s.key]
+		return val, ok
+	}
+	return nil, false
+}
+
+// Set sets the value the path points to in obj, creating the missing objects
+// along the way. Array elements can be replaced but arrays are not grown.
+func (p Path) Set(obj interface{}, value interface{}) error {
+	if len(p) == 0 {
+		return errors.New("jsonpath: cannot set the root")
+	}
+	cur := obj
+	for i, seg := range p[:len(p)-1] {
+		next, ok := seg.get(cur)
+		if !ok || next == nil {
+			parent, isObj := cur.(map[string]interface{})
+			if seg.isIndex || !isObj {
+				return fmt.Errorf("jsonpath: %s not found", p[:i+1])
+			}
+			next = make(map[string]interface{})
+			parent[seg.key] = next
+		}
+		cur = next
+	}
+	last := p[len(p)-1]
+	if last.isIndex {
+		arr, ok := cur.([]interface{})
+		i := last.index
+		if i < 0 {
+			i += len(arr)
+		}
+		if !ok || i < 0 || i >= len(arr) {
+			return fmt.Errorf("jsonpath: %s not found", p)
+		}
+		arr[i] = value
+		return nil
+	}
+	parent, ok := cur.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("jsonpath: %s is not an object", p[:len(p)-1])
+	}
+	parent[last.key] = value
+	return nil
+}
+
+// Delete removes the member the path points to from its object and reports
+// whether it existed. Array elements cannot be deleted.
+func (p Path) Delete(obj interface{}) bool {
+	if len(p) == 0 || p[len(p)-1].isIndex {
+		return false
+	}
+	parent, ok := p[:len(p)-1].Get(obj)
+	if !ok {
+		return false
+	}
+	m, ok := parent.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	key := p[len(p)-1].key
+	if _, ok := m[key]; !ok {
+		return false
+	}
+	delete(m, key)
+	return true
+}
+
+// Key returns the member name the path ends with, or an empty string when it
+// ends with an array index.
+func (p Path) Key() string {
+	if len(p) == 0 || p[len(p)-1].isIndex {
+		return ""
+	}
+	return p[len(p)-1].key
+}
+
+// String returns the path in its canonical form.
+func (p Path) String() string {
+	var sb strings.Builder
+	sb.WriteString(ROOT)
+	for _, seg := range p {
+		switch {
+		case seg.isIndex:
+			fmt.Fprintf(&sb, "[%d]", seg.index)
+		case identifier.MatchString(seg.key):
+			sb.WriteString("." + seg.key)
+		default:
+			fmt.Fprintf(&sb, "['%s']", seg.key)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,95 @@
+package jsonpath_test
+
+import (
+	"event-data-pipeline/pkg/jsonpath"
+	"reflect"
+	"testing"
+)
+
+func TestPath_Get(t *testing.T) {
+	obj := map[string]interface{}{
+		"value": map[string]interface{}{
+			"id":    float64(1),
+			"items": []interface{}{"a", "b", "c"},
+			"user": map[string]interface{}{
+				"first-name": "jane",
+			},
+		},
+		"headers": map[string]string{"trace.id": "abc"},
+	}
+	testCases := []struct {
+		expr  string
+		want  interface{}
+		found bool
+	}{
+		{expr: "$", want: obj, found: true},
+		{expr: "$.value.id", want: float64(1), found: true},
+		{expr: "$.value.items[1]", want: "b", found: true},
+		{expr: "$.value.items[-1]", want: "c", found: true},
+		{expr: "$.value.user['first-name']", want: "jane", found: true},
+		{expr: `$.headers["trace.id"]`, want: "abc", found: true},
+		{expr: "$.value.items[3]", found: false},
+		{expr: "$.value.missing", found: false},
+		{expr: "$.value.id.nested", found: false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.expr, func(t *testing.T) {
+			got, found := jsonpath.MustParse(tC.expr).Get(obj)
+			if found != tC.found {
+				t.Fatalf("expected found=%v, got %v", tC.found, found)
+			}
+			if found && !reflect.DeepEqual(got, tC.want) {
+				t.Errorf("expected %v, got %v", tC.want, got)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"value.id", "$.", "$.items[", "$.items[x]", "$value"} {
+		if _, err := jsonpath.Parse(expr); err == nil {
+			t.Errorf("expected an error for %q", expr)
+		}
+	}
+}
+
+func TestPath_String(t *testing.T) {
+	expr := "$.value.items[0]['first-name']"
+	if got := jsonpath.MustParse(expr).String(); got != expr {
+		t.Errorf("expected %s, got %s", expr, got)
+	}
+}
+
+func TestPath_SetDelete(t *testing.T) {
+	obj := map[string]interface{}{
+		"items": []interface{}{"a", "b"},
+		"id":    float64(1),
+	}
+	if err := jsonpath.MustParse("$.user.name").Set(obj, "jane"); err != nil {
+		t.Fatal(err)
+	}
+	if err := jsonpath.MustParse("$.items[-1]").Set(obj, "c"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"items": []interface{}{"a", "c"},
+		"id":    float64(1),
+		"user":  map[string]interface{}{"name": "jane"},
+	}
+	if !reflect.DeepEqual(obj, want) {
+		t.Errorf("expected %v, got %v", want, obj)
+	}
+
+	for _, expr := range []string{"$", "$.items[2]", "$.id.nested"} {
+		if err := jsonpath.MustParse(expr).Set(obj, "x"); err == nil {
+			t.Errorf("expected an error setting %s", expr)
+		}
+	}
+
+	if !jsonpath.MustParse("$.user.name").Delete(obj) {
+		t.Errorf("expected $.user.name to be deleted")
+	}
+	if jsonpath.MustParse("$.user.name").Delete(obj) || jsonpath.MustParse("$.items[0]").Delete(obj) {
+		t.Errorf("expected nothing to be deleted")
+	}
+}
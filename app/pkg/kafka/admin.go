@@ -4,40 +4,69 @@ import (
 	"encoding/json"
 	"errors"
 	"event-data-pipeline/pkg/logger"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 )
 
 type Admin interface {
-	GetPartitions() (*PartitionsResponse, error) // 카프카에서 Partition 정보를 가져오 메소드를 정의하고 있는 Admin 인터페이스
+	GetPartitions() ([]PartitionsResponse, error) // 카프카에서 Partition 정보를 가져오 메소드를 정의하고 있는 Admin 인터페이스
 }
 
 // Admin Class that implements Admin
 // Admin 인터페이스를 구현하는 Struct
 type AdminClient struct {
-	topic      string
+	topics     []string
+	patterns   []*regexp.Regexp
 	consumer   *kafka.Consumer
-	partitions []kafka.PartitionMetadata
+	partitions map[string][]kafka.PartitionMetadata
 }
 
 // Admin interface와 AdminClient struct 은 오로지 카프카의 파티션 정보를 가져오는 것만 관심을 가진다.
 // 이것에 대한 이점은 무멋이 있을까요?
 // AdminClient 를 Embedding 하는 struct 인 KafkaConsumer 타입은 초기화 작업 이후에 adminClient의 메소드를 그대로 이용할 수 있다.
 
-func NewAdminClient(topic string, consumer *kafka.Consumer) (*AdminClient, error) {
-	if topic == "" {
+// NewAdminClient creates an AdminClient for the given topics. A topic that
+// starts with "^" is a regular expression matched against all topic names,
+// the same convention librdkafka uses for subscriptions.
+func NewAdminClient(topics []string, consumer *kafka.Consumer) (*AdminClient, error) {
+	if len(topics) == 0 {
 		return nil, errors.New("topic is empty")
 	}
 	if consumer == nil {
 		return nil, errors.New("consumer is nil")
 	}
-	return &AdminClient{
-		topic:    topic,
+	ac := &AdminClient{
 		consumer: consumer,
-	}, nil
+	}
+	for _, topic := range topics {
+		if topic == "" {
+			return nil, errors.New("topic is empty")
+		}
+		if !IsTopicPattern(topic) {
+			ac.topics = append(ac.topics, topic)
+			continue
+		}
+		pattern, err := regexp.Compile(topic)
+		if err != nil {
+			return nil, fmt.Errorf("invalid topic pattern %s: %w", topic, err)
+		}
+		ac.patterns = append(ac.patterns, pattern)
+	}
+	return ac, nil
+}
+
+// IsTopicPattern reports whether the topic is a regular expression.
+func IsTopicPattern(topic string) bool {
+	return strings.HasPrefix(topic, TOPIC_PATTERN_PREFIX)
 }
 
-func (ac *AdminClient) GetPartitions() (*PartitionsResponse, error) {
+// GetPartitions returns the partitions of every topic that is configured or
+// matches one of the configured patterns, sorted by topic name.
+func (ac *AdminClient) GetPartitions() ([]PartitionsResponse, error) {
 	// create admin client from a consumer
 	adminClient, err := kafka.NewAdminClientFromConsumer(ac.consumer)
 	if err != nil {
@@ -45,21 +74,75 @@ func (ac *AdminClient) GetPartitions() (*PartitionsResponse, error) {
 	}
 	// close it on return
 	defer adminClient.Close()
-	md, err := adminClient.GetMetadata(&ac.topic, false, 5000)
+
+	// 토픽이 하나뿐이면 해당 토픽의 메타데이터만, 그 외에는 전체 토픽의 메타데이터를 가져온다.
+	var md *kafka.Metadata
+	if len(ac.topics) == 1 && len(ac.patterns) == 0 {
+		md, err = adminClient.GetMetadata(&ac.topics[0], false, 5000)
+	} else {
+		md, err = adminClient.GetMetadata(nil, true, 5000)
+	}
 	if err != nil {
 		return nil, err
 	}
 	raw, _ := json.Marshal(md)
 	logger.Debugf("metadata: %v", string(raw))
 
+	return ac.Partitions(md), nil
+}
+
+// Partitions keeps the partitions of the topics in the metadata that are
+// configured or match one of the configured patterns and returns them sorted
+// by topic name. Topics that are gone from the metadata are dropped.
+func (ac *AdminClient) Partitions(md *kafka.Metadata) []PartitionsResponse {
 	//set partitions data to the admin client
-	ac.partitions = md.Topics[ac.topic].Partitions
+	ac.partitions = make(map[string][]kafka.PartitionMetadata)
+	for name, topic := range md.Topics {
+		if !ac.Matches(name) {
+			continue
+		}
+		if topic.Error.Code() != kafka.ErrNoError {
+			logger.Errorf("error in topic %s metadata: %v", name, topic.Error)
+			continue
+		}
+		ac.partitions[name] = topic.Partitions
+	}
 
-	partitionsResponse := PartitionsResponse{
-		Topic: ac.topic,
+	partitionsResponses := make([]PartitionsResponse, 0, len(ac.partitions))
+	for name, partitions := range ac.partitions {
+		partitionsResponse := PartitionsResponse{
+			Topic: name,
+		}
+		for _, partition := range partitions {
+			partitionsResponse.Partitions = append(partitionsResponse.Partitions, float64(partition.ID))
+		}
+		sort.Float64s(partitionsResponse.Partitions)
+		partitionsResponses = append(partitionsResponses, partitionsResponse)
+	}
+	sort.Slice(partitionsResponses, func(i, j int) bool {
+		return partitionsResponses[i].Topic < partitionsResponses[j].Topic
+	})
+	if len(partitionsResponses) == 0 {
+		logger.Warnf("no topic matches %v %v", ac.topics, ac.patterns)
+	}
+	return partitionsResponses
+}
+
+// Matches reports whether the topic is configured or matches a pattern.
+// Internal topics such as __consumer_offsets only match when named exactly.
+func (ac *AdminClient) Matches(topic string) bool {
+	for _, t := range ac.topics {
+		if t == topic {
+			return true
+		}
+	}
+	if strings.HasPrefix(topic, INTERNAL_TOPIC_PREFIX) {
+		return false
 	}
-	for _, partition := range ac.partitions {
-		partitionsResponse.Partitions = append(partitionsResponse.Partitions, float64(partition.ID))
+	for _, pattern := range ac.patterns {
+		if pattern.MatchString(topic) {
+			return true
+		}
 	}
-	return &partitionsResponse, err
+	return false
 }
@@ -0,0 +1,118 @@
+package kafka_test
+
+import (
+	"event-data-pipeline/pkg/kafka"
+	"reflect"
+	"testing"
+
+	ckafka "github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+func newAdminClient(t *testing.T, topics ...string) *kafka.AdminClient {
+	t.Helper()
+	// 브로커에 연결하지 않아도 컨슈머는 생성된다.
+	consumer, err := ckafka.NewConsumer(&ckafka.ConfigMap{
+		"bootstrap.servers": "localhost:9",
+		"group.id":          "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	ac, err := kafka.NewAdminClient(topics, consumer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ac
+}
+
+func metadata(topics map[string]int) *ckafka.Metadata {
+	md := &ckafka.Metadata{Topics: make(map[string]ckafka.TopicMetadata)}
+	for name, partitions := range topics {
+		tm := ckafka.TopicMetadata{Topic: name}
+		for id := partitions - 1; id >= 0; id-- {
+			tm.Partitions = append(tm.Partitions, ckafka.PartitionMetadata{ID: int32(id)})
+		}
+		md.Topics[name] = tm
+	}
+	return md
+}
+
+func TestAdminClient_Matches(t *testing.T) {
+	tests := []struct {
+		name   string
+		topics []string
+		topic  string
+		want   bool
+	}{
+		{"exact", []string{"purchases"}, "purchases", true},
+		{"other topic", []string{"purchases"}, "refunds", false},
+		{"no substring match", []string{"purchases"}, "purchases.eu", false},
+		{"pattern", []string{`^purchases\..*`}, "purchases.eu", true},
+		{"pattern anchored", []string{`^purchases\..*`}, "old.purchases.eu", false},
+		{"pattern not matching", []string{`^purchases\..*`}, "purchases", false},
+		{"exact and pattern", []string{"refunds", `^purchases\..*`}, "refunds", true},
+		{"internal excluded from pattern", []string{`^.*`}, "__consumer_offsets", false},
+		{"internal by name", []string{"__consumer_offsets"}, "__consumer_offsets", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newAdminClient(t, tt.topics...).Matches(tt.topic); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAdminClient_InvalidPattern(t *testing.T) {
+	consumer, err := ckafka.NewConsumer(&ckafka.ConfigMap{
+		"bootstrap.servers": "localhost:9",
+		"group.id":          "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+
+	if _, err := kafka.NewAdminClient([]string{"^purchases["}, consumer); err == nil {
+		t.Fatal("expected an invalid pattern to be rejected")
+	}
+}
+
+func TestAdminClient_Partitions(t *testing.T) {
+	ac := newAdminClient(t, "refunds", `^purchases\..*`)
+
+	got := ac.Partitions(metadata(map[string]int{
+		"purchases.eu":       2,
+		"refunds":            1,
+		"orders":             3,
+		"__consumer_offsets": 50,
+	}))
+	want := []kafka.PartitionsResponse{
+		{Topic: "purchases.eu", Partitions: []float64{0, 1}},
+		{Topic: "refunds", Partitions: []float64{0}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	// 메타데이터를 다시 읽으면 새로 생긴 토픽과 파티션이 추가되고 사라진 토픽은 빠진다.
+	md := metadata(map[string]int{
+		"purchases.eu": 3,
+		"purchases.us": 1,
+	})
+	// 메타데이터 에러가 있는 토픽은 건너뛴다.
+	md.Topics["purchases.kr"] = ckafka.TopicMetadata{
+		Topic: "purchases.kr",
+		Error: ckafka.NewError(ckafka.ErrLeaderNotAvailable, "leader not available", false),
+	}
+	got = ac.Partitions(md)
+	want = []kafka.PartitionsResponse{
+		{Topic: "purchases.eu", Partitions: []float64{0, 1, 2}},
+		{Topic: "purchases.us", Partitions: []float64{0}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
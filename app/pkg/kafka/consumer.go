@@ -18,16 +18,18 @@ type Consumer interface {
 	CreateAdminConsumer() error
 	GetPartitions() error
 	Read(ctx context.Context) error // 이거 실제 구현체는 KafkaConsumer 스트럭 이라는 걸 알 수 있다.
-	AssignPartition(partition int) error
+	AssignPartition(topic string, partition int) error
 	Poll(ctx context.Context)
+	Subscribe() error
+	Commit() error
 	Stream() chan interface{}
 	PutPaylod(p payloads.Payload) error
 	GetPaylod() payloads.Payload
 }
 
 type KafkaConsumer struct {
-	// topic to consume from
-	topic string
+	// topics to consume from, a topic starting with ^ is a regular expression
+	topics []string
 
 	//configuration to create confluent-kafka-go consumer
 	configMap *kafka.ConfigMap
@@ -40,7 +42,12 @@ type KafkaConsumer struct {
 	//adminClient 만 변경 되면 partition를 가져오는 메소드는 KafkaConsumer 별도로 책임 질 필요가 없다. => 코드의 유지보수성이 높아진다.
 
 	//partitions response
-	partitions *PartitionsResponse
+	partitions []PartitionsResponse
+
+	// 파티션 별 컨슈머가 이미 생성된 파티션
+	assigned map[partitionKey]bool
+	// 새로 생성되었거나 패턴에 매칭되는 토픽을 찾기 위해 메타데이터를 다시 읽는 주기
+	metadataRefreshInterval time.Duration
 
 	ctx context.Context
 
@@ -49,11 +56,27 @@ type KafkaConsumer struct {
 	errCh chan error
 
 	payload payloads.Payload
+
+	// enable.auto.commit 이 꺼져 있으면 처리가 끝난 오프셋을 직접 커밋
+	manualCommit   bool
+	commitInterval time.Duration
+	offsets        *OffsetTracker
+
+	// 컨슈머 그룹 모드에서는 파티션을 직접 할당하지 않고 토픽을 구독
+	groupMode        bool
+	rebalanceTimeout time.Duration
 }
 
+const (
+	DEFAULT_COMMIT_INTERVAL_MS     = 5000
+	DEFAULT_REBALANCE_TIMEOUT_MS   = 10000
+	DEFAULT_METADATA_REFRESH_MS    = 30000
+	REBALANCE_PROTOCOL_COOPERATIVE = "COOPERATIVE"
+)
+
 func NewKafkaConsumer(config jsonObj) *KafkaConsumer {
-	topic, ok := config["topic"].(string)
-	if !ok {
+	topics, ok := config["topics"].([]string)
+	if !ok || len(topics) == 0 {
 		logger.Panicf("no topic provided")
 	}
 	//context, stream, errch 추출
@@ -70,19 +93,67 @@ func NewKafkaConsumer(config jsonObj) *KafkaConsumer {
 	var kcm kafka.ConfigMap
 	json.Unmarshal(cfgMapData, &kcm)
 
+	commitInterval := DEFAULT_COMMIT_INTERVAL_MS * time.Millisecond
+	if ms, ok := config["commitIntervalMs"].(int); ok && ms > 0 {
+		commitInterval = time.Duration(ms) * time.Millisecond
+	}
+
+	groupMode, _ := config["groupMode"].(bool)
+	rebalanceTimeout := DEFAULT_REBALANCE_TIMEOUT_MS * time.Millisecond
+	if ms, ok := config["rebalanceTimeoutMs"].(int); ok && ms > 0 {
+		rebalanceTimeout = time.Duration(ms) * time.Millisecond
+	}
+
+	// 0 이면 기본값, 음수이면 메타데이터를 다시 읽지 않는다.
+	metadataRefreshInterval := DEFAULT_METADATA_REFRESH_MS * time.Millisecond
+	if ms, ok := config["metadataRefreshIntervalMs"].(int); ok && ms != 0 {
+		metadataRefreshInterval = time.Duration(ms) * time.Millisecond
+	}
+	if groupMode && metadataRefreshInterval > 0 {
+		// 그룹 모드에서는 librdkafka 가 메타데이터를 갱신하면서 패턴에 매칭되는 토픽을 구독한다.
+		if v, _ := kcm.Get("topic.metadata.refresh.interval.ms", nil); v == nil {
+			kcm.SetKey("topic.metadata.refresh.interval.ms", int(metadataRefreshInterval.Milliseconds()))
+		}
+	}
+
 	// create a new KafkaConsumer with configMap fed in
 	kafkaConsumer := &KafkaConsumer{
-		topic:     topic,
-		configMap: &kcm,
-		ctx:       ctx,
-		stream:    stream,
-		errCh:     errch,
+		topics:         topics,
+		configMap:      &kcm,
+		ctx:            ctx,
+		stream:         stream,
+		errCh:          errch,
+		manualCommit:   !autoCommitEnabled(&kcm),
+		commitInterval: commitInterval,
+		offsets:        NewOffsetTracker(),
+
+		groupMode:        groupMode,
+		rebalanceTimeout: rebalanceTimeout,
+
+		assigned:                make(map[partitionKey]bool),
+		metadataRefreshInterval: metadataRefreshInterval,
 	}
 
 	return kafkaConsumer
 
 }
 
+// autoCommitEnabled reports whether enable.auto.commit is on. It is on
+// unless the consumer options turn it off explicitly.
+func autoCommitEnabled(cm *kafka.ConfigMap) bool {
+	v, err := cm.Get("enable.auto.commit", true)
+	if err != nil {
+		return true
+	}
+	switch enabled := v.(type) {
+	case bool:
+		return enabled
+	case string:
+		return enabled != "false"
+	}
+	return true
+}
+
 func extractPipeParams(config jsonObj) (context.Context, chan interface{}, chan error) {
 	pipeParams, ok := config["pipeParams"].(map[string]interface{})
 	if !ok {
@@ -127,7 +198,7 @@ func (kc *KafkaConsumer) CreateConsumer() error {
 
 func (kc *KafkaConsumer) CreateAdminConsumer() error {
 	var err error
-	kc.adminClient, err = NewAdminClient(kc.topic, kc.kafkaConsumer)
+	kc.adminClient, err = NewAdminClient(kc.topics, kc.kafkaConsumer)
 	if err != nil {
 		return err
 	}
@@ -145,35 +216,169 @@ func (kc *KafkaConsumer) GetPartitions() error {
 }
 
 func (kc *KafkaConsumer) Read(ctx context.Context) error { // 카푸카와 커넥션을 맺은 다음 데이터를 읽어 오는 비즈니스 로직이 구현 되어 있다.
+	// 컨슈머 그룹 모드: 파티션 할당을 그룹 코디네이터에 맡겨 여러 레플리카가 파티션을 나눠 읽는다.
+	if kc.groupMode {
+		err := kc.Subscribe()
+		if err != nil {
+			return err
+		}
+		go kc.Poll(ctx)
+		return nil
+	}
+
+	kc.assignNew(ctx)
+
+	// 새로 생긴 토픽이나 파티션을 재시작 없이 읽기 위해 주기적으로 메타데이터를 갱신
+	if kc.metadataRefreshInterval > 0 {
+		go kc.refreshPartitions(ctx)
+	}
+	return nil
+}
+
+// assignNew starts a consumer for every partition that has no consumer yet.
+func (kc *KafkaConsumer) assignNew(ctx context.Context) {
 	// 파티션 별로 카프카 컨슈머 생성
-	for _, p := range kc.partitions.Partitions {
-		// KafkaConsumer 인스턴스 복사
-		ckc := kc.Copy()
-		// KafkaConsumer 내부 실제 컨슈머 생성
-		ckc.CreateConsumer()
-		// 데이터를 읽어오기 위한 파티션에 할당
-		ckc.AssignPartition(int(p))
-		// 실제 데이터를 읽어오는 고루틴 생성
-		go ckc.Poll(ctx) // 비동기식으로 컨슈머별로 데이터를 읽어오는 고루틴을 실행함
+	for _, tp := range kc.partitions {
+		for _, p := range tp.Partitions {
+			key := partitionKey{tp.Topic, int32(p)}
+			if kc.assigned[key] {
+				continue
+			}
+			// KafkaConsumer 인스턴스 복사
+			ckc := kc.Copy()
+			// KafkaConsumer 내부 실제 컨슈머 생성
+			err := ckc.CreateConsumer()
+			if err != nil {
+				logger.Errorf("error in creating consumer for %s[%d]: %v", tp.Topic, int(p), err)
+				continue
+			}
+			// 데이터를 읽어오기 위한 파티션에 할당
+			err = ckc.AssignPartition(tp.Topic, int(p))
+			if err != nil {
+				logger.Errorf("error in assigning %s[%d]: %v", tp.Topic, int(p), err)
+				ckc.kafkaConsumer.Close()
+				continue
+			}
+			kc.assigned[key] = true
+			logger.Infof("consuming from [Topic: %s][Partition: %d]", tp.Topic, int(p))
+			// 실제 데이터를 읽어오는 고루틴 생성
+			go ckc.Poll(ctx) // 비동기식으로 컨슈머별로 데이터를 읽어오는 고루틴을 실행함
+		}
+	}
+}
+
+// refreshPartitions periodically re-reads the topic metadata and starts
+// consumers for topics and partitions that appeared since the last refresh.
+func (kc *KafkaConsumer) refreshPartitions(ctx context.Context) {
+	ticker := time.NewTicker(kc.metadataRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := kc.GetPartitions()
+			if err != nil {
+				logger.Errorf("error in refreshing partitions: %v", err)
+				continue
+			}
+			kc.assignNew(ctx)
+		}
+	}
+}
+
+// Subscribe implements Consumer. It joins the consumer group of group.id
+// and lets the group coordinator assign partitions to this consumer.
+func (kc *KafkaConsumer) Subscribe() error {
+	return kc.kafkaConsumer.SubscribeTopics(kc.topics, func(c *kafka.Consumer, ev kafka.Event) error {
+		return kc.Rebalance(c, ev)
+	})
+}
+
+// GroupConsumer is the part of *kafka.Consumer a rebalance needs.
+type GroupConsumer interface {
+	String() string
+	GetRebalanceProtocol() string
+	AssignmentLost() bool
+	Assign(partitions []kafka.TopicPartition) error
+	Unassign() error
+	IncrementalAssign(partitions []kafka.TopicPartition) error
+	IncrementalUnassign(partitions []kafka.TopicPartition) error
+	CommitOffsets(offsets []kafka.TopicPartition) ([]kafka.TopicPartition, error)
+}
+
+// Rebalance handles partition assignment changes of the consumer group.
+// Before partitions are given up, payloads read from them are flushed through
+// the pipeline and their offsets committed, so the next owner of the
+// partitions does not re-read them.
+func (kc *KafkaConsumer) Rebalance(c GroupConsumer, ev kafka.Event) error {
+	cooperative := c.GetRebalanceProtocol() == REBALANCE_PROTOCOL_COOPERATIVE
+
+	switch e := ev.(type) {
+	case kafka.AssignedPartitions:
+		logger.Infof("[Consumer: %s] partitions assigned: %v", c.String(), e.Partitions)
+		if cooperative {
+			return c.IncrementalAssign(e.Partitions)
+		}
+		return c.Assign(e.Partitions)
+	case kafka.RevokedPartitions:
+		logger.Infof("[Consumer: %s] partitions revoked: %v", c.String(), e.Partitions)
+		if c.AssignmentLost() {
+			// 이미 다른 컨슈머에게 넘어간 파티션은 커밋할 수 없다.
+			logger.Warnf("[Consumer: %s] partitions lost, in-flight payloads will be redelivered", c.String())
+		} else if kc.manualCommit {
+			kc.flush(e.Partitions)
+			kc.commit(c)
+		}
+		kc.offsets.Forget(e.Partitions)
+		if cooperative {
+			return c.IncrementalUnassign(e.Partitions)
+		}
+		return c.Unassign()
 	}
 	return nil
 }
 
+// Offsets returns the tracker of the offsets read by this consumer.
+func (kc *KafkaConsumer) Offsets() *OffsetTracker {
+	return kc.offsets
+}
+
+// flush waits until the payloads read from the partitions have been handled
+// or the rebalance timeout expires.
+func (kc *KafkaConsumer) flush(tps []kafka.TopicPartition) {
+	deadline := time.Now().Add(kc.rebalanceTimeout)
+	for {
+		inflight := kc.offsets.Inflight(tps)
+		if inflight == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			logger.Warnf("rebalance timeout: %d payloads still in flight, they will be redelivered", inflight)
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
 // Copy KafkaConsumer instance
 func (kc *KafkaConsumer) Copy() *KafkaConsumer {
 	return &KafkaConsumer{
-		topic:     kc.topic,
-		configMap: kc.configMap,
-		stream:    kc.stream,
-		errCh:     kc.errCh,
+		topics:         kc.topics,
+		configMap:      kc.configMap,
+		stream:         kc.stream,
+		errCh:          kc.errCh,
+		manualCommit:   kc.manualCommit,
+		commitInterval: kc.commitInterval,
+		offsets:        NewOffsetTracker(),
 	}
 }
 
-func (kc *KafkaConsumer) AssignPartition(partition int) error {
+func (kc *KafkaConsumer) AssignPartition(topic string, partition int) error {
 
 	var partitions []kafka.TopicPartition
 
-	tp := NewTopicPartition(kc.topic, partition)
+	tp := NewTopicPartition(topic, partition)
 	partitions = append(partitions, *tp)
 
 	err := kc.kafkaConsumer.Assign(partitions)
@@ -193,6 +398,15 @@ func (kc *KafkaConsumer) Poll(ctx context.Context) { //실제로 데이터를 
 		record["offset"] = float64(msg.TopicPartition.Offset)
 
 		record["key"] = string(msg.Key)
+		record["raw"] = msg.Value
+
+		if len(msg.Headers) > 0 {
+			headers := make(map[string]string, len(msg.Headers))
+			for _, h := range msg.Headers {
+				headers[h.Key] = string(h.Value)
+			}
+			record["headers"] = headers
+		}
 
 		var valObj map[string]interface{}
 
@@ -202,12 +416,25 @@ func (kc *KafkaConsumer) Poll(ctx context.Context) { //실제로 데이터를 
 		}
 		record["value"] = valObj
 		record["timestamp"] = msg.Timestamp
+
+		// 싱크에 쓰기가 완료되면 오프셋을 커밋할 수 있도록 전달 핸들을 추가
+		if kc.manualCommit {
+			record[payloads.ACK_KEY] = kc.offsets.Track(*msg.TopicPartition.Topic, msg.TopicPartition.Partition, msg.TopicPartition.Offset)
+		}
 		return record
 	}
+	lastCommit := time.Now()
 	for {
+		if kc.manualCommit && time.Since(lastCommit) >= kc.commitInterval {
+			kc.Commit()
+			lastCommit = time.Now()
+		}
 		select {
 		case <-ctx.Done():
 			logger.Infof("shutting down consumer read")
+			if kc.manualCommit {
+				kc.Commit()
+			}
 			return
 		default:
 			ev := kc.kafkaConsumer.Poll(100)
@@ -231,6 +458,28 @@ func (kc *KafkaConsumer) Poll(ctx context.Context) { //실제로 데이터를 
 	}
 }
 
+// Commit implements Consumer. It commits the highest contiguous offset of
+// each partition whose payloads have been written by all storages.
+func (kc *KafkaConsumer) Commit() error {
+	return kc.commit(kc.kafkaConsumer)
+}
+
+func (kc *KafkaConsumer) commit(c GroupConsumer) error {
+	tps := kc.offsets.Committable()
+	if len(tps) == 0 {
+		return nil
+	}
+	committed, err := c.CommitOffsets(tps)
+	if err != nil {
+		logger.Errorf("error in committing offsets %v: %v", tps, err)
+		return err
+	}
+	kc.offsets.Committed(committed)
+	ConsumerCommitTotal.Inc()
+	logger.Debugf("committed offsets: %v", committed)
+	return nil
+}
+
 // GetPaylod implements Consumer
 func (kc *KafkaConsumer) GetPaylod() payloads.Payload {
 	return kc.payload
@@ -8,6 +8,15 @@ import (
 const (
 	EDP_KAFKA_CONSUMER_READ_TOTAL      = "edp_kafka_consumer_read_total"
 	EDP_KAFKA_CONSUMER_READ_TOTAL_HELP = "the number of messages that kafka consumer reads in total"
+
+	EDP_KAFKA_CONSUMER_COMMIT_TOTAL      = "edp_kafka_consumer_commit_total"
+	EDP_KAFKA_CONSUMER_COMMIT_TOTAL_HELP = "the number of offset commits that kafka consumer made in total"
+
+	EDP_KAFKA_PRODUCER_WRITE_TOTAL      = "edp_kafka_producer_write_total"
+	EDP_KAFKA_PRODUCER_WRITE_TOTAL_HELP = "the number of messages that kafka producer delivered in total"
+
+	EDP_KAFKA_PRODUCER_ERROR_TOTAL      = "edp_kafka_producer_error_total"
+	EDP_KAFKA_PRODUCER_ERROR_TOTAL_HELP = "the number of messages that kafka producer failed to deliver in total"
 )
 
 var (
@@ -15,8 +24,23 @@ var (
 		Name: EDP_KAFKA_CONSUMER_READ_TOTAL,
 		Help: EDP_KAFKA_CONSUMER_READ_TOTAL_HELP},
 	)
+	ConsumerCommitTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: EDP_KAFKA_CONSUMER_COMMIT_TOTAL,
+		Help: EDP_KAFKA_CONSUMER_COMMIT_TOTAL_HELP},
+	)
+	ProducerWriteTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: EDP_KAFKA_PRODUCER_WRITE_TOTAL,
+		Help: EDP_KAFKA_PRODUCER_WRITE_TOTAL_HELP},
+	)
+	ProducerErrorTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: EDP_KAFKA_PRODUCER_ERROR_TOTAL,
+		Help: EDP_KAFKA_PRODUCER_ERROR_TOTAL_HELP},
+	)
 )
 
 func init() {
 	prometheus.Register(ConsumerReadTotal)
+	prometheus.Register(ConsumerCommitTotal)
+	prometheus.Register(ProducerWriteTotal)
+	prometheus.Register(ProducerErrorTotal)
 }
@@ -0,0 +1,171 @@
+package kafka
+
+import (
+	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/payloads"
+	"sync"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+type partitionKey struct {
+	topic     string
+	partition int32
+}
+
+// partitionOffsets keeps the offsets read from one partition in read order.
+type partitionOffsets struct {
+	inflight []int64
+	acked    map[int64]bool
+	// 처리에 실패한 오프셋, 이 오프셋부터는 커밋하지 않는다.
+	failed map[int64]bool
+	// next offset to commit, i.e. the highest contiguous acked offset + 1
+	committable kafka.Offset
+	committed   kafka.Offset
+}
+
+// OffsetTracker tracks the messages read from each partition and computes
+// the highest contiguous offset whose payloads have been fully handled.
+// Offsets after a payload still in flight are not committable, so a restart
+// re-reads everything that was not written yet (at-least-once delivery).
+// A payload that failed, i.e. that the error policy of the pipeline neither
+// wrote nor sent to the dead letter storage, blocks the commits of its
+// partition for good: it is re-read once the consumer restarts or the
+// partition is assigned again. It no longer counts as in flight, so revokes
+// do not wait for it.
+type OffsetTracker struct {
+	mu         sync.Mutex
+	partitions map[partitionKey]*partitionOffsets
+}
+
+func NewOffsetTracker() *OffsetTracker {
+	return &OffsetTracker{
+		partitions: make(map[partitionKey]*partitionOffsets),
+	}
+}
+
+// Track registers a message that has been read and returns the Ack its
+// payload has to carry through the pipeline.
+func (t *OffsetTracker) Track(topic string, partition int32, offset kafka.Offset) *payloads.Ack {
+	key := partitionKey{topic, partition}
+
+	t.mu.Lock()
+	po, ok := t.partitions[key]
+	if !ok {
+		po = &partitionOffsets{
+			acked:       make(map[int64]bool),
+			failed:      make(map[int64]bool),
+			committable: kafka.OffsetInvalid,
+			committed:   kafka.OffsetInvalid,
+		}
+		t.partitions[key] = po
+	}
+	po.inflight = append(po.inflight, int64(offset))
+	t.mu.Unlock()
+
+	return payloads.NewAck(
+		func() { t.ack(key, po, int64(offset)) },
+		func(err error) {
+			logger.Errorf("[Topic: %s][Partition: %d][Offset: %d] not processed: %v", topic, partition, offset, err)
+			t.fail(key, po, int64(offset))
+		},
+	)
+}
+
+func (t *OffsetTracker) ack(key partitionKey, po *partitionOffsets, offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// 파티션이 해제된 이후의 Ack 는 무시
+	if t.partitions[key] != po {
+		return
+	}
+	po.acked[offset] = true
+
+	// 가장 앞에서부터 연속으로 처리된 오프셋까지 커밋 가능
+	for len(po.inflight) > 0 && po.acked[po.inflight[0]] {
+		delete(po.acked, po.inflight[0])
+		po.committable = kafka.Offset(po.inflight[0] + 1)
+		po.inflight = po.inflight[1:]
+	}
+}
+
+// fail keeps the commits of the partition before the offset, so the payload
+// is read again.
+func (t *OffsetTracker) fail(key partitionKey, po *partitionOffsets, offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.partitions[key] != po {
+		return
+	}
+	po.failed[offset] = true
+}
+
+// Committable returns the offsets that can be committed since the last call
+// to Committed.
+func (t *OffsetTracker) Committable() []kafka.TopicPartition {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var tps []kafka.TopicPartition
+	for key, po := range t.partitions {
+		if po.committable == kafka.OffsetInvalid || po.committable == po.committed {
+			continue
+		}
+		topic := key.topic
+		tps = append(tps, kafka.TopicPartition{
+			Topic:     &topic,
+			Partition: key.partition,
+			Offset:    po.committable,
+		})
+	}
+	return tps
+}
+
+// Committed records the offsets that have been committed.
+func (t *OffsetTracker) Committed(tps []kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tp := range tps {
+		if tp.Topic == nil || tp.Error != nil {
+			continue
+		}
+		if po, ok := t.partitions[partitionKey{*tp.Topic, tp.Partition}]; ok {
+			po.committed = tp.Offset
+		}
+	}
+}
+
+// Inflight returns the number of payloads read from the given partitions that
+// have been neither acknowledged nor failed yet.
+func (t *OffsetTracker) Inflight(tps []kafka.TopicPartition) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	inflight := 0
+	for _, tp := range tps {
+		if tp.Topic == nil {
+			continue
+		}
+		if po, ok := t.partitions[partitionKey{*tp.Topic, tp.Partition}]; ok {
+			inflight += len(po.inflight) - len(po.acked) - len(po.failed)
+		}
+	}
+	return inflight
+}
+
+// Forget stops tracking the given partitions, e.g. after they have been
+// revoked from this consumer. Acks that arrive afterwards are ignored.
+func (t *OffsetTracker) Forget(tps []kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tp := range tps {
+		if tp.Topic == nil {
+			continue
+		}
+		delete(t.partitions, partitionKey{*tp.Topic, tp.Partition})
+	}
+}
@@ -0,0 +1,106 @@
+package kafka_test
+
+import (
+	"errors"
+	"event-data-pipeline/pkg/kafka"
+	"testing"
+
+	ckafka "github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+func TestOffsetTracker_Committable(t *testing.T) {
+	tracker := kafka.NewOffsetTracker()
+
+	acks := make([]interface{ Done() }, 0)
+	for offset := 10; offset < 15; offset++ {
+		acks = append(acks, tracker.Track("purchases", 0, ckafka.Offset(offset)))
+	}
+
+	// 앞선 오프셋이 처리되지 않았으면 커밋할 수 없다.
+	acks[1].Done()
+	acks[2].Done()
+	if tps := tracker.Committable(); len(tps) != 0 {
+		t.Fatalf("expected nothing to commit, got %v", tps)
+	}
+
+	// 10, 11, 12 가 연속으로 처리되었으므로 다음 오프셋 13 을 커밋
+	acks[0].Done()
+	tps := tracker.Committable()
+	if len(tps) != 1 || tps[0].Offset != 13 || *tps[0].Topic != "purchases" || tps[0].Partition != 0 {
+		t.Fatalf("expected purchases[0]@13, got %v", tps)
+	}
+	tracker.Committed(tps)
+	if tps := tracker.Committable(); len(tps) != 0 {
+		t.Fatalf("expected nothing new to commit, got %v", tps)
+	}
+
+	// 팬아웃된 페이로드는 모든 핸들러가 끝나야 커밋 가능
+	fanout := tracker.Track("purchases", 0, 15)
+	fanout.Add(1)
+	acks[3].Done()
+	acks[4].Done()
+	fanout.Done()
+	if tps := tracker.Committable(); len(tps) != 1 || tps[0].Offset != 15 {
+		t.Fatalf("expected purchases[0]@15, got %v", tps)
+	}
+	fanout.Done()
+	if tps := tracker.Committable(); len(tps) != 1 || tps[0].Offset != 16 {
+		t.Fatalf("expected purchases[0]@16, got %v", tps)
+	}
+}
+
+func TestOffsetTracker_Failed(t *testing.T) {
+	tracker := kafka.NewOffsetTracker()
+	topic := "purchases"
+	partition := []ckafka.TopicPartition{{Topic: &topic, Partition: 1}}
+
+	done := tracker.Track(topic, 1, 0)
+	failed := tracker.Track(topic, 1, 1)
+	later := tracker.Track(topic, 1, 2)
+	pending := tracker.Track(topic, 1, 3)
+
+	// 실패한 오프셋 앞까지만 커밋하고 이후 오프셋은 처리되어도 커밋하지 않는다.
+	done.Done()
+	failed.Fail(errors.New("write failed"))
+	later.Done()
+	if tps := tracker.Committable(); len(tps) != 1 || tps[0].Offset != 1 {
+		t.Fatalf("expected purchases[1]@1, got %v", tps)
+	}
+	// 실패한 오프셋은 처리 중으로 세지 않는다.
+	if n := tracker.Inflight(partition); n != 1 {
+		t.Fatalf("expected 1 payload in flight, got %d", n)
+	}
+
+	// 실패 이후의 Done 은 무시된다.
+	failed.Done()
+	pending.Done()
+	if tps := tracker.Committable(); len(tps) != 1 || tps[0].Offset != 1 {
+		t.Fatalf("expected the commit to stay at purchases[1]@1, got %v", tps)
+	}
+	if n := tracker.Inflight(partition); n != 0 {
+		t.Fatalf("expected nothing in flight, got %d", n)
+	}
+}
+
+func TestOffsetTracker_Forget(t *testing.T) {
+	tracker := kafka.NewOffsetTracker()
+	topic := "purchases"
+	revoked := []ckafka.TopicPartition{{Topic: &topic, Partition: 2}}
+
+	late := tracker.Track(topic, 2, 100)
+	if n := tracker.Inflight(revoked); n != 1 {
+		t.Fatalf("expected 1 payload in flight, got %d", n)
+	}
+
+	// 회수된 파티션의 Ack 는 다시 할당된 이후의 오프셋에 영향을 주지 않는다.
+	tracker.Forget(revoked)
+	reassigned := tracker.Track(topic, 2, 200)
+	late.Done()
+	if tps := tracker.Committable(); len(tps) != 0 {
+		t.Fatalf("expected nothing to commit, got %v", tps)
+	}
+	reassigned.Done()
+	if tps := tracker.Committable(); len(tps) != 1 || tps[0].Offset != 201 {
+		t.Fatalf("expected purchases[2]@201, got %v", tps)
+	}
+}
@@ -1,4 +1,125 @@
 package kafka
 
+import (
+	"encoding/json"
+	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/payloads"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+var _ Producer = new(KafkaProducer)
+
 type Producer interface {
+	CreateProducer() error
+	Produce(topic string, key, value []byte, ack *payloads.Ack) error
+	Flush(timeout time.Duration) int
+	Close()
+}
+
+const (
+	// 로컬 프로듀서 큐가 가득 찼을 때 전송을 기다리는 시간
+	DEFAULT_QUEUE_FULL_BACKOFF_MS = 100
+	DEFAULT_FLUSH_TIMEOUT_MS      = 10000
+)
+
+type KafkaProducer struct {
+	//configuration to create confluent-kafka-go producer
+	configMap *kafka.ConfigMap
+
+	//confluent kafka go producer
+	kafkaProducer *kafka.Producer
+}
+
+func NewKafkaProducer(config jsonObj) *KafkaProducer {
+	//producerOptions
+	kfkPrdcrCfg, ok := config["producerOptions"].(map[string]interface{})
+	if !ok {
+		logger.Panicf("no producer options provided")
+	}
+
+	// load Producer Options to kafka.ConfigMap
+	cfgMapData, _ := json.Marshal(kfkPrdcrCfg)
+	var kcm kafka.ConfigMap
+	json.Unmarshal(cfgMapData, &kcm)
+
+	return &KafkaProducer{
+		configMap: &kcm,
+	}
+}
+
+// CreateProducer creates the confluent-kafka-go producer and starts handling
+// its delivery reports.
+func (kp *KafkaProducer) CreateProducer() error {
+	var err error
+	kp.kafkaProducer, err = kafka.NewProducer(kp.configMap)
+	if err != nil {
+		return err
+	}
+	logger.Debugf("Check in producer creation: %s", kp.kafkaProducer)
+
+	go kp.deliveryReports()
+	return nil
+}
+
+// Produce queues a message for delivery. The message is sent asynchronously;
+// the ack is done once the broker has acknowledged the message, or failed
+// when it could not be delivered.
+func (kp *KafkaProducer) Produce(topic string, key, value []byte, ack *payloads.Ack) error {
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            key,
+		Value:          value,
+		Opaque:         ack,
+	}
+	for {
+		err := kp.kafkaProducer.Produce(msg, nil)
+		if err == nil {
+			return nil
+		}
+		// 큐가 가득 차면 전송될 때까지 기다렸다가 다시 시도
+		if kerr, ok := err.(kafka.Error); ok && kerr.Code() == kafka.ErrQueueFull {
+			logger.Debugf("producer queue is full, waiting for deliveries...")
+			kp.kafkaProducer.Flush(DEFAULT_QUEUE_FULL_BACKOFF_MS)
+			continue
+		}
+		return err
+	}
+}
+
+// deliveryReports acknowledges or rejects the payload of every message
+// according to its delivery report.
+func (kp *KafkaProducer) deliveryReports() {
+	for ev := range kp.kafkaProducer.Events() {
+		switch e := ev.(type) {
+		case *kafka.Message:
+			ack, _ := e.Opaque.(*payloads.Ack)
+			if e.TopicPartition.Error != nil {
+				ProducerErrorTotal.Inc()
+				logger.Errorf("[Topic: %s] delivery failed: %v", *e.TopicPartition.Topic, e.TopicPartition.Error)
+				ack.Fail(e.TopicPartition.Error)
+				continue
+			}
+			ProducerWriteTotal.Inc()
+			logger.Debugf("[Topic: %s][Partition: %d][Offset: %d] delivered", *e.TopicPartition.Topic, e.TopicPartition.Partition, e.TopicPartition.Offset)
+			ack.Done()
+		case kafka.Error:
+			logger.Errorf("Error: %v: %v", e.Code(), e)
+		}
+	}
+}
+
+// Flush waits for outstanding messages to be delivered and returns the
+// number of messages still in the queue.
+func (kp *KafkaProducer) Flush(timeout time.Duration) int {
+	return kp.kafkaProducer.Flush(int(timeout.Milliseconds()))
+}
+
+// Close flushes outstanding messages and closes the producer.
+func (kp *KafkaProducer) Close() {
+	if remaining := kp.Flush(DEFAULT_FLUSH_TIMEOUT_MS * time.Millisecond); remaining > 0 {
+		logger.Warnf("closing producer with %d messages not delivered", remaining)
+	}
+	kp.kafkaProducer.Close()
 }
@@ -0,0 +1,177 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"event-data-pipeline/pkg/kafka"
+	"sync"
+	"testing"
+	"time"
+
+	ckafka "github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+// fakeGroupConsumer records the calls a rebalance makes to the group consumer.
+type fakeGroupConsumer struct {
+	mu        sync.Mutex
+	protocol  string
+	lost      bool
+	calls     []string
+	committed []ckafka.TopicPartition
+}
+
+func (f *fakeGroupConsumer) record(call string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	return nil
+}
+
+func (f *fakeGroupConsumer) String() string               { return "fake" }
+func (f *fakeGroupConsumer) GetRebalanceProtocol() string { return f.protocol }
+func (f *fakeGroupConsumer) AssignmentLost() bool         { return f.lost }
+func (f *fakeGroupConsumer) Assign([]ckafka.TopicPartition) error {
+	return f.record("Assign")
+}
+func (f *fakeGroupConsumer) Unassign() error { return f.record("Unassign") }
+func (f *fakeGroupConsumer) IncrementalAssign([]ckafka.TopicPartition) error {
+	return f.record("IncrementalAssign")
+}
+func (f *fakeGroupConsumer) IncrementalUnassign([]ckafka.TopicPartition) error {
+	return f.record("IncrementalUnassign")
+}
+func (f *fakeGroupConsumer) CommitOffsets(tps []ckafka.TopicPartition) ([]ckafka.TopicPartition, error) {
+	f.record("CommitOffsets")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.committed = append(f.committed, tps...)
+	return tps, nil
+}
+
+func newGroupConsumer(t *testing.T) *kafka.KafkaConsumer {
+	t.Helper()
+	return kafka.NewKafkaConsumer(map[string]interface{}{
+		"topics": []string{"purchases"},
+		"consumerOptions": map[string]interface{}{
+			"group.id":           "test",
+			"enable.auto.commit": false,
+		},
+		"groupMode":          true,
+		"rebalanceTimeoutMs": 2000,
+		"pipeParams": map[string]interface{}{
+			"context": context.Background(),
+			"stream":  make(chan interface{}),
+			"errch":   make(chan error),
+		},
+	})
+}
+
+func TestKafkaConsumer_RebalanceAssign(t *testing.T) {
+	topic := "purchases"
+	partitions := []ckafka.TopicPartition{{Topic: &topic, Partition: 0}}
+
+	tests := []struct {
+		protocol string
+		want     string
+	}{
+		{"EAGER", "Assign"},
+		{kafka.REBALANCE_PROTOCOL_COOPERATIVE, "IncrementalAssign"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.protocol, func(t *testing.T) {
+			c := &fakeGroupConsumer{protocol: tt.protocol}
+			if err := newGroupConsumer(t).Rebalance(c, ckafka.AssignedPartitions{Partitions: partitions}); err != nil {
+				t.Fatal(err)
+			}
+			if len(c.calls) != 1 || c.calls[0] != tt.want {
+				t.Fatalf("expected [%s], got %v", tt.want, c.calls)
+			}
+		})
+	}
+}
+
+func TestKafkaConsumer_RebalanceRevoke(t *testing.T) {
+	topic := "purchases"
+	revoked := []ckafka.TopicPartition{{Topic: &topic, Partition: 0}}
+
+	tests := []struct {
+		protocol string
+		unassign string
+	}{
+		{"EAGER", "Unassign"},
+		{kafka.REBALANCE_PROTOCOL_COOPERATIVE, "IncrementalUnassign"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.protocol, func(t *testing.T) {
+			kc := newGroupConsumer(t)
+			c := &fakeGroupConsumer{protocol: tt.protocol}
+
+			done := kc.Offsets().Track(topic, 0, 10)
+			inflight := kc.Offsets().Track(topic, 0, 11)
+			done.Done()
+
+			// 회수 중에 남은 페이로드가 처리되면 그 오프셋까지 커밋한다.
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+				inflight.Done()
+			}()
+			if err := kc.Rebalance(c, ckafka.RevokedPartitions{Partitions: revoked}); err != nil {
+				t.Fatal(err)
+			}
+
+			want := []string{"CommitOffsets", tt.unassign}
+			if len(c.calls) != 2 || c.calls[0] != want[0] || c.calls[1] != want[1] {
+				t.Fatalf("expected %v, got %v", want, c.calls)
+			}
+			if len(c.committed) != 1 || c.committed[0].Offset != 12 {
+				t.Fatalf("expected purchases[0]@12 to be committed, got %v", c.committed)
+			}
+			if n := kc.Offsets().Inflight(revoked); n != 0 {
+				t.Fatalf("expected revoked partitions to be forgotten, got %d in flight", n)
+			}
+		})
+	}
+}
+
+func TestKafkaConsumer_RebalanceRevokeFailed(t *testing.T) {
+	topic := "purchases"
+	revoked := []ckafka.TopicPartition{{Topic: &topic, Partition: 0}}
+	kc := newGroupConsumer(t)
+	c := &fakeGroupConsumer{protocol: "EAGER"}
+
+	// 실패한 오프셋은 기다리지 않지만 커밋하지도 않아 다음 소유자가 다시 읽는다.
+	kc.Offsets().Track(topic, 0, 9).Done()
+	kc.Offsets().Track(topic, 0, 10).Fail(errors.New("write failed"))
+	kc.Offsets().Track(topic, 0, 11).Done()
+
+	start := time.Now()
+	if err := kc.Rebalance(c, ckafka.RevokedPartitions{Partitions: revoked}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected revoke not to wait for failed offsets, took %v", elapsed)
+	}
+	if len(c.committed) != 1 || c.committed[0].Offset != 10 {
+		t.Fatalf("expected purchases[0]@10 to be committed, got %v", c.committed)
+	}
+}
+
+func TestKafkaConsumer_RebalanceLost(t *testing.T) {
+	topic := "purchases"
+	revoked := []ckafka.TopicPartition{{Topic: &topic, Partition: 0}}
+	kc := newGroupConsumer(t)
+	c := &fakeGroupConsumer{protocol: kafka.REBALANCE_PROTOCOL_COOPERATIVE, lost: true}
+
+	// 잃어버린 파티션은 커밋하지 않고 처리 중인 페이로드도 기다리지 않는다.
+	late := kc.Offsets().Track(topic, 0, 10)
+	if err := kc.Rebalance(c, ckafka.RevokedPartitions{Partitions: revoked}); err != nil {
+		t.Fatal(err)
+	}
+	late.Done()
+	if len(c.calls) != 1 || c.calls[0] != "IncrementalUnassign" {
+		t.Fatalf("expected [IncrementalUnassign], got %v", c.calls)
+	}
+	if tps := kc.Offsets().Committable(); len(tps) != 0 {
+		t.Fatalf("expected nothing to commit after the partitions were lost, got %v", tps)
+	}
+}
@@ -4,6 +4,12 @@ import "github.com/confluentinc/confluent-kafka-go/kafka"
 
 type jsonObj map[string]interface{}
 
+const (
+	// 토픽 이름이 ^ 로 시작하면 정규표현식으로 취급
+	TOPIC_PATTERN_PREFIX  = "^"
+	INTERNAL_TOPIC_PREFIX = "__"
+)
+
 type PartitionsResponse struct {
 	Topic      string    `json:"topic"`
 	Partitions []float64 `json:"partitions"`
@@ -0,0 +1,102 @@
+package payloads
+
+import "sync/atomic"
+
+// ACK_KEY is the record key sources use to hand a payload's Ack over from
+// the consumer that read it.
+const ACK_KEY = "ack"
+
+// Ack is the delivery handle of a payload. It is shared by every clone of the
+// payload and tells the source once the payload has been handled by every
+// stage and storage it was sent to, so the source can commit or acknowledge
+// the underlying message.
+//
+// An Ack starts with one pending handler. Stages that broadcast a payload to
+// several outputs Add the extra handlers; storages call Done once the payload
+// has been written. All methods are safe on a nil Ack.
+type Ack struct {
+	pending int32
+	settled int32
+	onAck   func()
+	onNack  func(error)
+}
+
+// NewAck returns an Ack that calls onAck once all handlers are done, or
+// onNack when one of them fails first. Either callback may be nil.
+func NewAck(onAck func(), onNack func(error)) *Ack {
+	return &Ack{pending: 1, onAck: onAck, onNack: onNack}
+}
+
+// Add registers n more handlers of the payload.
+func (a *Ack) Add(n int) {
+	if a == nil || n <= 0 {
+		return
+	}
+	atomic.AddInt32(&a.pending, int32(n))
+}
+
+// Done marks one handler as finished.
+func (a *Ack) Done() {
+	if a == nil {
+		return
+	}
+	if atomic.AddInt32(&a.pending, -1) == 0 && atomic.CompareAndSwapInt32(&a.settled, 0, 1) {
+		if a.onAck != nil {
+			a.onAck()
+		}
+	}
+}
+
+// Fail reports that the payload could not be handled. Only the first failure
+// is reported and the payload is never acknowledged afterwards.
+func (a *Ack) Fail(err error) {
+	if a == nil {
+		return
+	}
+	if atomic.CompareAndSwapInt32(&a.settled, 0, 1) {
+		if a.onNack != nil {
+			a.onNack(err)
+		}
+	}
+}
+
+// Acknowledger is implemented by payloads that carry a delivery handle back
+// to their source.
+type Acknowledger interface {
+	GetAck() *Ack
+	SetAck(*Ack)
+}
+
+// Intercept replaces the Ack of the payload with one that passes successes on
+// to the original Ack but reports failures to onFail, together with the
+// original Ack, instead. It returns false if the payload cannot carry an Ack.
+func Intercept(p interface{}, onFail func(parent *Ack, err error)) bool {
+	a, ok := p.(Acknowledger)
+	if !ok {
+		return false
+	}
+	parent := a.GetAck()
+	a.SetAck(NewAck(parent.Done, func(err error) { onFail(parent, err) }))
+	return true
+}
+
+// Acknowledge marks one handler of the payload as done, if it carries an Ack.
+func Acknowledge(p interface{}) {
+	if a, ok := p.(Acknowledger); ok {
+		a.GetAck().Done()
+	}
+}
+
+// Reject reports a failure to handle the payload, if it carries an Ack.
+func Reject(p interface{}, err error) {
+	if a, ok := p.(Acknowledger); ok {
+		a.GetAck().Fail(err)
+	}
+}
+
+// Fanout registers n more handlers of the payload, if it carries an Ack.
+func Fanout(p interface{}, n int) {
+	if a, ok := p.(Acknowledger); ok {
+		a.GetAck().Add(n)
+	}
+}
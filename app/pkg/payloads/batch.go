@@ -0,0 +1,31 @@
+package payloads
+
+var _ Payload = (Batch)(nil)
+
+// Batch is returned by processors splitting a payload into several ones.
+// Stage runners pass each payload of the batch to the next stages on its own.
+//
+// The payloads of a batch carry the Ack of the payload they were split from,
+// which is acknowledged once all of them are done.
+type Batch []Payload
+
+// Clone implements Payload
+func (b Batch) Clone() Payload {
+	newB := make(Batch, len(b))
+	for i, p := range b {
+		newB[i] = p.Clone()
+	}
+	return newB
+}
+
+// Out implements Payload. A batch is never written as is.
+func (b Batch) Out() (string, string, []byte) {
+	return "", "", nil
+}
+
+// MarkAsProcessed implements Payload
+func (b Batch) MarkAsProcessed() {
+	for _, p := range b {
+		p.MarkAsProcessed()
+	}
+}
@@ -0,0 +1,81 @@
+package payloads
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+var (
+	// 컴파일 타임 타입 변경 체크
+	_ Payload      = (*DeadLetter)(nil)
+	_ Acknowledger = (*DeadLetter)(nil)
+)
+
+// DEAD_LETTER_INDEX is the index dead letters are written to.
+const DEAD_LETTER_INDEX = "dead_letter"
+
+// DeadLetter is a payload that failed a pipeline stage or could not be
+// written by a storage, together with the reason it failed. It takes over the
+// Ack of the failed payload, so the source message is only acknowledged once
+// the dead letter itself has been written.
+type DeadLetter struct {
+	Payload   Payload   `json:"payload,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Stage     int       `json:"stage"`
+	Timestamp time.Time `json:"timestamp,omitempty"`
+
+	Ack *Ack `json:"-"`
+}
+
+// NewDeadLetter wraps a payload that failed at the given stage.
+func NewDeadLetter(p Payload, err error, stage int) *DeadLetter {
+	dl := &DeadLetter{
+		Payload:   p,
+		Stage:     stage,
+		Timestamp: time.Now(),
+	}
+	if err != nil {
+		dl.Error = err.Error()
+	}
+	if a, ok := p.(Acknowledger); ok {
+		dl.Ack = a.GetAck()
+	}
+	return dl
+}
+
+// Clone implements Payload
+func (dl *DeadLetter) Clone() Payload {
+	newP := *dl
+	return &newP
+}
+
+// Out implements Payload. A dead letter keeps the document ID of the failed
+// payload when it has one, so it can be matched with the original document.
+func (dl *DeadLetter) Out() (string, string, []byte) {
+	var docID string
+	if dl.Payload != nil {
+		_, docID, _ = dl.Payload.Out()
+	}
+	if docID == "" {
+		docID = strconv.FormatInt(dl.Timestamp.UnixNano(), 10)
+	}
+	data, _ := json.Marshal(dl)
+	return DEAD_LETTER_INDEX, docID, data
+}
+
+// GetAck implements Acknowledger
+func (dl *DeadLetter) GetAck() *Ack {
+	return dl.Ack
+}
+
+// SetAck implements Acknowledger
+func (dl *DeadLetter) SetAck(a *Ack) {
+	dl.Ack = a
+}
+
+// MarkAsProcessed implements Payload
+func (dl *DeadLetter) MarkAsProcessed() {
+	dl.Payload = nil
+	dl.Ack = nil
+}
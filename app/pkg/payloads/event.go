@@ -0,0 +1,264 @@
+package payloads
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// 컴파일 타임 타입 변경 체크
+	_ Payload      = (*Event)(nil)
+	_ Acknowledger = (*Event)(nil)
+
+	eventPool = sync.Pool{
+		New: func() interface{} { return new(Event) },
+	}
+)
+
+// 이벤트를 읽어온 소스 종류
+const (
+	SOURCE_KAFKA    = "kafka"
+	SOURCE_RABBITMQ = "rabbitmq"
+)
+
+// EventSource holds the coordinates of the message an Event was read from.
+// Only the fields of the source type are set.
+type EventSource struct {
+	Type string `json:"type,omitempty"`
+
+	// kafka
+	Topic     string `json:"topic,omitempty"`
+	Partition int32  `json:"partition,omitempty"`
+	Offset    int64  `json:"offset,omitempty"`
+
+	// rabbitmq
+	Queue      string `json:"queue,omitempty"`
+	Exchange   string `json:"exchange,omitempty"`
+	RoutingKey string `json:"routing_key,omitempty"`
+}
+
+// Event is the schema-less payload every source produces. Processors work on
+// Events regardless of the source they come from, so a new topic or queue
+// needs no payload type of its own.
+type Event struct {
+	Source    EventSource            `json:"source"`
+	Key       string                 `json:"key,omitempty"`
+	Headers   map[string]string      `json:"headers,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Value     map[string]interface{} `json:"value,omitempty"`
+	Raw       []byte                 `json:"raw,omitempty"`
+	Timestamp time.Time              `json:"timestamp,omitempty"`
+
+	Index string `json:"index,omitempty"`
+	DocID string `json:"doc_id,omitempty"`
+	Data  []byte `json:"data,omitempty"`
+
+	// 소스에 처리 완료를 알리기 위한 전달 핸들
+	Ack *Ack `json:"-"`
+}
+
+// NewEventFromRecord creates an Event from a record read by a consumer. The
+// record keys are the ones consumers put on their stream: topic, partition,
+// offset, key, queue, exchange, routing_key, headers, value, raw, timestamp
+// and ack.
+func NewEventFromRecord(sourceType string, record map[string]interface{}) *Event {
+	e := &Event{
+		Source: EventSource{
+			Type:       sourceType,
+			Topic:      stringOf(record["topic"]),
+			Partition:  int32(numberOf(record["partition"])),
+			Offset:     int64(numberOf(record["offset"])),
+			Queue:      stringOf(record["queue"]),
+			Exchange:   stringOf(record["exchange"]),
+			RoutingKey: stringOf(record["routing_key"]),
+		},
+		Key: stringOf(record["key"]),
+	}
+	e.Value, _ = record["value"].(map[string]interface{})
+	e.Raw, _ = record["raw"].([]byte)
+	e.Timestamp, _ = record["timestamp"].(time.Time)
+	e.Ack, _ = record[ACK_KEY].(*Ack)
+	switch headers := record["headers"].(type) {
+	case map[string]string:
+		e.Headers = headers
+	case map[string]interface{}:
+		e.Headers = make(map[string]string, len(headers))
+		for k, v := range headers {
+			e.Headers[k] = fmt.Sprint(v)
+		}
+	}
+	return e
+}
+
+// AsEvent returns the payload as an Event. The legacy per-source payloads are
+// converted into a new Event that carries their Ack.
+func AsEvent(p Payload) (*Event, error) {
+	switch pl := p.(type) {
+	case *Event:
+		return pl, nil
+	case *KafkaPayload:
+		return &Event{
+			Source: EventSource{
+				Type:      SOURCE_KAFKA,
+				Topic:     pl.Topic,
+				Partition: int32(pl.Partition),
+				Offset:    int64(pl.Offset),
+			},
+			Key:       pl.Key,
+			Value:     pl.Value,
+			Timestamp: pl.Timestamp,
+			Index:     pl.Index,
+			DocID:     pl.DocID,
+			Data:      pl.Data,
+			Ack:       pl.Ack,
+		}, nil
+	case *RabbitMQPayload:
+		return &Event{
+			Source: EventSource{
+				Type:  SOURCE_RABBITMQ,
+				Queue: pl.Queue,
+			},
+			Value:     pl.Value,
+			Timestamp: pl.Timestamp,
+			Index:     pl.Index,
+			DocID:     pl.DocID,
+			Data:      pl.Data,
+			Ack:       pl.Ack,
+		}, nil
+	case nil:
+		return nil, errors.New("payload is nil")
+	}
+	return nil, fmt.Errorf("unsupported payload type %T", p)
+}
+
+// Fields returns the event as a json object for expressions to look fields
+// up: source, key, headers, metadata, value, timestamp, index and doc_id. The
+// partition and offset are int64, so large offsets are not rounded or
+// formatted as floats. The maps of the event are shared, not copied.
+func (e *Event) Fields() map[string]interface{} {
+	headers := make(map[string]interface{}, len(e.Headers))
+	for k, v := range e.Headers {
+		headers[k] = v
+	}
+	return map[string]interface{}{
+		"source": map[string]interface{}{
+			"type":        e.Source.Type,
+			"topic":       e.Source.Topic,
+			"partition":   int64(e.Source.Partition),
+			"offset":      e.Source.Offset,
+			"queue":       e.Source.Queue,
+			"exchange":    e.Source.Exchange,
+			"routing_key": e.Source.RoutingKey,
+		},
+		"key":       e.Key,
+		"headers":   headers,
+		"metadata":  e.Metadata,
+		"value":     e.Value,
+		"timestamp": e.Timestamp,
+		"index":     e.Index,
+		"doc_id":    e.DocID,
+	}
+}
+
+// Clone implements Payload. Value and Metadata are deep-copied, so the clone
+// can be modified without affecting other clones.
+func (e *Event) Clone() Payload {
+	newP := eventPool.Get().(*Event)
+
+	newP.Source = e.Source
+	newP.Key = e.Key
+	newP.Headers = e.Headers
+	newP.Metadata = copyObj(e.Metadata)
+	newP.Value = copyObj(e.Value)
+	newP.Raw = e.Raw
+	newP.Timestamp = e.Timestamp
+
+	newP.Index = e.Index
+	newP.DocID = e.DocID
+	newP.Data = e.Data
+
+	newP.Ack = e.Ack
+
+	return newP
+}
+
+// Out implements Payload
+func (e *Event) Out() (string, string, []byte) {
+	return e.Index, e.DocID, e.Data
+}
+
+// GetAck implements Acknowledger
+func (e *Event) GetAck() *Ack {
+	return e.Ack
+}
+
+// SetAck implements Acknowledger
+func (e *Event) SetAck(a *Ack) {
+	e.Ack = a
+}
+
+// MarkAsProcessed implements Payload
+func (e *Event) MarkAsProcessed() {
+	e.Source = EventSource{}
+	e.Key = ""
+	e.Headers = nil
+	e.Metadata = nil
+	e.Value = nil
+	e.Raw = nil
+	e.Timestamp = time.Time{}
+
+	e.Index = ""
+	e.DocID = ""
+	e.Data = nil
+
+	e.Ack = nil
+
+	eventPool.Put(e)
+}
+
+// copyObj deep-copies a json object.
+func copyObj(obj map[string]interface{}) map[string]interface{} {
+	if obj == nil {
+		return nil
+	}
+	newObj := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		newObj[k] = copyValue(v)
+	}
+	return newObj
+}
+
+func copyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return copyObj(val)
+	case []interface{}:
+		arr := make([]interface{}, len(val))
+		for i, item := range val {
+			arr[i] = copyValue(item)
+		}
+		return arr
+	}
+	return v
+}
+
+func stringOf(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func numberOf(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	}
+	return 0
+}
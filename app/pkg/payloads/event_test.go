@@ -0,0 +1,85 @@
+package payloads_test
+
+import (
+	"event-data-pipeline/pkg/payloads"
+	"testing"
+	"time"
+)
+
+func TestNewEventFromRecord(t *testing.T) {
+	ts := time.Now()
+	ack := payloads.NewAck(nil, nil)
+	record := map[string]interface{}{
+		"topic":     "purchases",
+		"partition": float64(2),
+		"offset":    float64(42),
+		"key":       "user-1",
+		"headers":   map[string]string{"trace-id": "abc"},
+		"value":     map[string]interface{}{"price": float64(10)},
+		"raw":       []byte(`{"price":10}`),
+		"timestamp": ts,
+		"ack":       ack,
+	}
+	e := payloads.NewEventFromRecord(payloads.SOURCE_KAFKA, record)
+
+	if e.Source.Type != payloads.SOURCE_KAFKA || e.Source.Topic != "purchases" || e.Source.Partition != 2 || e.Source.Offset != 42 {
+		t.Errorf("unexpected source: %+v", e.Source)
+	}
+	if e.Key != "user-1" || e.Headers["trace-id"] != "abc" || e.Value["price"] != float64(10) || string(e.Raw) != `{"price":10}` {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if !e.Timestamp.Equal(ts) || e.GetAck() != ack {
+		t.Errorf("expected timestamp and ack to be carried over")
+	}
+}
+
+func TestAsEvent(t *testing.T) {
+	ack := payloads.NewAck(nil, nil)
+	testCases := []struct {
+		desc    string
+		payload payloads.Payload
+		source  payloads.EventSource
+	}{
+		{
+			desc:    "kafka payload",
+			payload: &payloads.KafkaPayload{Topic: "purchases", Partition: 1, Offset: 7, Value: map[string]interface{}{}, Ack: ack},
+			source:  payloads.EventSource{Type: payloads.SOURCE_KAFKA, Topic: "purchases", Partition: 1, Offset: 7},
+		},
+		{
+			desc:    "rabbitmq payload",
+			payload: &payloads.RabbitMQPayload{Queue: "users", Value: map[string]interface{}{}, Ack: ack},
+			source:  payloads.EventSource{Type: payloads.SOURCE_RABBITMQ, Queue: "users"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			e, err := payloads.AsEvent(tC.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e.Source != tC.source || e.GetAck() != ack {
+				t.Errorf("expected source %+v with ack, got %+v", tC.source, e.Source)
+			}
+		})
+	}
+
+	if _, err := payloads.AsEvent(&payloads.DeadLetter{}); err == nil {
+		t.Errorf("expected an error for an unsupported payload")
+	}
+}
+
+func TestEvent_Clone(t *testing.T) {
+	e := &payloads.Event{
+		Value: map[string]interface{}{
+			"user": map[string]interface{}{"id": float64(1)},
+			"tags": []interface{}{"a"},
+		},
+	}
+	clone := e.Clone().(*payloads.Event)
+	clone.Value["user"].(map[string]interface{})["id"] = float64(2)
+	clone.Value["tags"].([]interface{})[0] = "b"
+
+	if e.Value["user"].(map[string]interface{})["id"] != float64(1) || e.Value["tags"].([]interface{})[0] != "a" {
+		t.Errorf("modifying a clone changed the original: %v", e.Value)
+	}
+}
@@ -7,7 +7,8 @@ import (
 
 var (
 	// 컴파일 타임 타입 변경 체크
-	_ Payload = (*KafkaPayload)(nil)
+	_ Payload      = (*KafkaPayload)(nil)
+	_ Acknowledger = (*KafkaPayload)(nil)
 
 	kafkaPayloadPool = sync.Pool{
 		New: func() interface{} { return new(KafkaPayload) }, //사용했던 인스턴스를 다시 반환
@@ -15,6 +16,8 @@ var (
 )
 
 // 페이로드를 구현하는 상위 모 구현하는 구현체이다.
+//
+// Deprecated: sources produce Event; use AsEvent to convert a KafkaPayload.
 type KafkaPayload struct {
 	Topic     string                 `json:"topic,omitempty"`
 	Partition float64                `json:"partition,omitempty"`
@@ -26,6 +29,9 @@ type KafkaPayload struct {
 	Index string `json:"index,omitempty"`
 	DocID string `json:"doc_id,omitempty"`
 	Data  []byte `json:"data,omitempty"`
+
+	// 오프셋 커밋을 위한 전달 핸들
+	Ack *Ack `json:"-"`
 }
 
 // Clone implements pipeline.Payload.
@@ -42,6 +48,8 @@ func (kp *KafkaPayload) Clone() Payload { // 디카피하는 내용이다.
 	newP.DocID = kp.DocID
 	newP.Data = kp.Data
 
+	newP.Ack = kp.Ack
+
 	return newP
 }
 
@@ -50,6 +58,16 @@ func (kp *KafkaPayload) Out() (string, string, []byte) {
 	return kp.Index, kp.DocID, kp.Data // 원형 형태로 output 하는 형식이다.
 }
 
+// GetAck implements Acknowledger
+func (kp *KafkaPayload) GetAck() *Ack {
+	return kp.Ack
+}
+
+// SetAck implements Acknowledger
+func (kp *KafkaPayload) SetAck(a *Ack) {
+	kp.Ack = a
+}
+
 // MarkAsProcessed implements pipeline.Payload
 func (p *KafkaPayload) MarkAsProcessed() { // 더이상 처리할 필요가 없는 경우
 
@@ -64,5 +82,7 @@ func (p *KafkaPayload) MarkAsProcessed() { // 더이상 처리할 필요가 없
 	p.Index = ""
 	p.Data = nil
 
+	p.Ack = nil
+
 	kafkaPayloadPool.Put(p) //PayloadPool이라고 하는 메모리를 효율적으로 관리하기 위한 기법
 }
@@ -7,13 +7,17 @@ import (
 
 var (
 	// 컴파일 타임 타입 변경 체크
-	_ Payload = (*RabbitMQPayload)(nil)
+	_ Payload      = (*RabbitMQPayload)(nil)
+	_ Acknowledger = (*RabbitMQPayload)(nil)
 
 	rabbitMQPayloadPool = sync.Pool{
 		New: func() interface{} { return new(RabbitMQPayload) },
 	}
 )
 
+// RabbitMQPayload is the payload of the users queue.
+//
+// Deprecated: sources produce Event; use AsEvent to convert a RabbitMQPayload.
 type RabbitMQPayload struct {
 	Id        int    `json:"id,omitempty"`
 	Email     string `json:"email,omitempty"`
@@ -28,6 +32,9 @@ type RabbitMQPayload struct {
 	Index string `json:"index,omitempty"`
 	DocID string `json:"doc_id,omitempty"`
 	Data  []byte `json:"data,omitempty"`
+
+	// manual ack 모드에서 메시지를 ack 하기 위한 전달 핸들
+	Ack *Ack `json:"-"`
 }
 
 // Clone implements pipeline.Payload.
@@ -45,6 +52,8 @@ func (kp *RabbitMQPayload) Clone() Payload {
 	newP.DocID = kp.DocID
 	newP.Data = kp.Data
 
+	newP.Ack = kp.Ack
+
 	return newP
 }
 
@@ -53,6 +62,16 @@ func (kp *RabbitMQPayload) Out() (string, string, []byte) {
 	return kp.Index, kp.DocID, kp.Data
 }
 
+// GetAck implements Acknowledger
+func (kp *RabbitMQPayload) GetAck() *Ack {
+	return kp.Ack
+}
+
+// SetAck implements Acknowledger
+func (kp *RabbitMQPayload) SetAck(a *Ack) {
+	kp.Ack = a
+}
+
 // MarkAsProcessed implements pipeline.Payload
 func (p *RabbitMQPayload) MarkAsProcessed() {
 	p.Id = 0
@@ -68,5 +87,7 @@ func (p *RabbitMQPayload) MarkAsProcessed() {
 	p.Index = ""
 	p.Data = nil
 
+	p.Ack = nil
+
 	rabbitMQPayloadPool.Put(p)
 }
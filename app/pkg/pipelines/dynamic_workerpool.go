@@ -0,0 +1,245 @@
+package pipelines
+
+import (
+	"context"
+	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/processors"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const DEFAULT_SCALE_INTERVAL_MS = 1000
+
+func init() {
+	Register("dynamic_pool", NewDynamicWorkerPool)
+}
+
+// DynamicPoolCfg is the runner config of the dynamic_pool StageRunner.
+type DynamicPoolCfg struct {
+	MinWorkers      int `json:"min_workers,omitempty"`
+	MaxWorkers      int `json:"max_workers,omitempty"`
+	ScaleIntervalMs int `json:"scale_interval_ms,omitempty"`
+	QueueSize       int `json:"queue_size,omitempty"`
+}
+
+type dynamicWorkerPool struct {
+	proc          processors.Processor
+	minWorkers    int
+	maxWorkers    int
+	scaleInterval time.Duration
+	queueSize     int
+}
+
+// NewDynamicWorkerPool is the StageRunnerFactory for DynamicWorkerPool.
+// min_workers falls back to 1 and max_workers to min_workers.
+func NewDynamicWorkerPool(proc processors.Processor, config jsonObj) (StageRunner, error) {
+	var cfg DynamicPoolCfg
+	if err := loadRunnerConfig(config, &cfg); err != nil {
+		return nil, err
+	}
+
+	minWorkers := 1
+	if cfg.MinWorkers > 0 {
+		minWorkers = cfg.MinWorkers
+	}
+	maxWorkers := minWorkers
+	if cfg.MaxWorkers > minWorkers {
+		maxWorkers = cfg.MaxWorkers
+	}
+	scaleInterval := DEFAULT_SCALE_INTERVAL_MS * time.Millisecond
+	if cfg.ScaleIntervalMs > 0 {
+		scaleInterval = time.Duration(cfg.ScaleIntervalMs) * time.Millisecond
+	}
+
+	pool := DynamicWorkerPool(proc, minWorkers, maxWorkers, scaleInterval).(*dynamicWorkerPool)
+	if cfg.QueueSize > 0 {
+		pool.queueSize = cfg.QueueSize
+	}
+	return pool, nil
+}
+
+// DynamicWorkerPool returns a StageRunner that processes incoming payloads in
+// parallel with a number of workers that grows and shrinks between
+// minWorkers and maxWorkers.
+//
+// Every scaleInterval the pool estimates the number of workers it needs from
+// the arrival rate and the processing latency observed during the interval
+// (Little's law) and from the backlog waiting in its queue. It grows to that
+// number at once so bursts are absorbed quickly, and shrinks by one idle
+// worker per interval so it does not flap.
+func DynamicWorkerPool(proc processors.Processor, minWorkers, maxWorkers int, scaleInterval time.Duration) StageRunner {
+	if minWorkers <= 0 {
+		panic("DynamicWorkerPool: minWorkers must be > 0")
+	}
+	if maxWorkers < minWorkers {
+		panic("DynamicWorkerPool: maxWorkers must be >= minWorkers")
+	}
+	if scaleInterval <= 0 {
+		panic("DynamicWorkerPool: scaleInterval must be > 0")
+	}
+	return &dynamicWorkerPool{
+		proc:          proc,
+		minWorkers:    minWorkers,
+		maxWorkers:    maxWorkers,
+		scaleInterval: scaleInterval,
+		queueSize:     maxWorkers,
+	}
+}
+
+// poolStats collects what the pool observed during the current scale interval.
+type poolStats struct {
+	arrived   int64
+	processed int64
+	busyNanos int64
+}
+
+// reset returns the arrivals, the processed count and the total processing
+// time of the interval and starts a new one.
+func (s *poolStats) reset() (int64, int64, time.Duration) {
+	return atomic.SwapInt64(&s.arrived, 0),
+		atomic.SwapInt64(&s.processed, 0),
+		time.Duration(atomic.SwapInt64(&s.busyNanos, 0))
+}
+
+// timedProcessor records the processing latency of the wrapped processor.
+// Only the processor call is timed so that a slow downstream stage does not
+// look like slow processing.
+type timedProcessor struct {
+	proc  processors.Processor
+	stats *poolStats
+}
+
+func (t timedProcessor) Process(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+	start := time.Now()
+	out, err := t.proc.Process(ctx, p)
+	atomic.AddInt64(&t.stats.busyNanos, int64(time.Since(start)))
+	atomic.AddInt64(&t.stats.processed, 1)
+	return out, err
+}
+
+// Run implements StageRunner.
+func (p *dynamicWorkerPool) Run(ctx context.Context, params StageParams) {
+	ctx, cancelFn := context.WithCancel(ctx)
+	defer cancelFn()
+
+	stats := &poolStats{}
+	runner := fifo{proc: timedProcessor{proc: p.proc, stats: stats}}
+	stage := strconv.Itoa(params.StageIndex())
+
+	// 입력 채널은 버퍼가 없으므로 내부 큐로 옮겨 담아 backlog 를 측정
+	queue := make(chan payloads.Payload, p.queueSize)
+	go func() {
+		defer close(queue)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case payload, ok := <-params.Input():
+				if !ok || payload == nil {
+					return
+				}
+				atomic.AddInt64(&stats.arrived, 1)
+				select {
+				case queue <- payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	quit := make(chan struct{})
+	exited := make(chan struct{})
+	workers := 0
+	spawn := func() {
+		workers++
+		go func() {
+			p.work(ctx, cancelFn, runner, params, queue, quit)
+			exited <- struct{}{}
+		}()
+	}
+	for workers < p.minWorkers {
+		spawn()
+	}
+	dynamicPoolWorkers.WithLabelValues(stage).Set(float64(workers))
+
+	ticker := time.NewTicker(p.scaleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-exited:
+			workers--
+			dynamicPoolWorkers.WithLabelValues(stage).Set(float64(workers))
+			// 큐가 닫혔거나 컨텍스트가 취소되어 모든 워커가 종료된 경우
+			if workers == 0 {
+				logger.Debugf("shutting down dynamic worker pool run...")
+				return
+			}
+		case <-ticker.C:
+			arrived, processed, busy := stats.reset()
+			desired := p.desiredWorkers(workers, len(queue), arrived, processed, busy)
+			if desired > workers {
+				logger.Debugf("stage %s: scaling dynamic worker pool up %d -> %d", stage, workers, desired)
+				for workers < desired {
+					spawn()
+				}
+			} else if desired < workers {
+				// 처리 중이 아닌 워커만 quit 신호를 받을 수 있다.
+				select {
+				case quit <- struct{}{}:
+					logger.Debugf("stage %s: scaling dynamic worker pool down from %d", stage, workers)
+				default:
+				}
+			}
+			dynamicPoolWorkers.WithLabelValues(stage).Set(float64(workers))
+		}
+	}
+}
+
+// work is a single worker of the pool. It returns when the queue is closed,
+// the context is cancelled, the pool asks it to quit or the stage fails, in
+// which case it cancels the pool so the other workers stop as well.
+func (p *dynamicWorkerPool) work(ctx context.Context, cancelFn context.CancelFunc, runner fifo, params StageParams, queue <-chan payloads.Payload, quit <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-quit:
+			return
+		case payloadIn, ok := <-queue:
+			if !ok {
+				return
+			}
+			if !runner.process(ctx, params, payloadIn) {
+				// 실패한 스테이지가 나머지 페이로드를 계속 처리하지 않도록 풀 전체를 멈춘다.
+				cancelFn()
+				return
+			}
+		}
+	}
+}
+
+// desiredWorkers estimates the number of workers needed to keep up with the
+// input. The estimate is the arrival rate multiplied by the average latency,
+// plus one worker for each payload that is still waiting in the queue.
+func (p *dynamicWorkerPool) desiredWorkers(workers, backlog int, arrived, processed int64, busy time.Duration) int {
+	desired := 0
+	if processed > 0 {
+		rate := float64(arrived) / p.scaleInterval.Seconds()
+		latency := busy.Seconds() / float64(processed)
+		desired = int(math.Ceil(rate * latency))
+	}
+	if backlog > 0 && desired < workers+backlog {
+		desired = workers + backlog
+	}
+	if desired < p.minWorkers {
+		desired = p.minWorkers
+	}
+	if desired > p.maxWorkers {
+		desired = p.maxWorkers
+	}
+	return desired
+}
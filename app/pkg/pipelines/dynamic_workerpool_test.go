@@ -0,0 +1,171 @@
+package pipelines_test
+
+import (
+	"context"
+	"errors"
+	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/pipelines"
+	"event-data-pipeline/pkg/processors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDynamicWorkerPool_Run(t *testing.T) {
+	os.Args = nil
+	os.Setenv("EDP_ENABLE_DEBUG_LOGGING", "false")
+	logger.Setup()
+
+	// 처리 시간이 긴 프로세서의 동시 실행 수를 기록
+	var running, maxRunning int64
+	slow := processors.ProcessorFunc(func(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+		n := atomic.AddInt64(&running, 1)
+		for {
+			m := atomic.LoadInt64(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt64(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt64(&running, -1)
+		return p, nil
+	})
+
+	pool := pipelines.DynamicWorkerPool(slow, 1, 8, 10*time.Millisecond)
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFunc()
+
+	inCh := make(chan payloads.Payload)
+	outCh := make(chan payloads.Payload)
+	errCh := make(chan error, 1)
+	params := &stageParams{stage: 0, inCh: inCh, outCh: []chan<- payloads.Payload{outCh}, errCh: errCh}
+
+	done := make(chan struct{})
+	go func() {
+		pool.Run(ctx, params)
+		close(done)
+	}()
+
+	count := 200
+	go func() {
+		for i := 0; i < count; i++ {
+			inCh <- &stubPayload{id: i}
+		}
+		close(inCh)
+	}()
+
+	received := 0
+	for received < count {
+		select {
+		case <-outCh:
+			received++
+		case err := <-errCh:
+			t.Fatal(err)
+		case <-ctx.Done():
+			t.Fatalf("received %d of %d payloads before timeout", received, count)
+		}
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("dynamic worker pool did not stop after its input was closed")
+	}
+
+	if atomic.LoadInt64(&maxRunning) < 2 {
+		t.Errorf("expected the pool to scale up, max concurrent workers: %d", maxRunning)
+	}
+	if atomic.LoadInt64(&maxRunning) > 8 {
+		t.Errorf("pool exceeded max workers: %d", maxRunning)
+	}
+}
+
+func TestDynamicWorkerPool_RunFailure(t *testing.T) {
+	// 두번째 페이로드만 실패하는 프로세서
+	failing := processors.ProcessorFunc(func(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+		if p.(*stubPayload).id == 1 {
+			return nil, errors.New("process failed")
+		}
+		return p, nil
+	})
+
+	pool := pipelines.DynamicWorkerPool(failing, 4, 4, time.Hour)
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+
+	// 입력을 닫지 않아도 실패한 워커가 풀 전체를 멈춰야 한다.
+	inCh := make(chan payloads.Payload)
+	outCh := make(chan payloads.Payload)
+	errCh := make(chan error, 1)
+	params := &stageParams{stage: 0, inCh: inCh, outCh: []chan<- payloads.Payload{outCh}, errCh: errCh}
+
+	done := make(chan struct{})
+	go func() {
+		pool.Run(ctx, params)
+		close(done)
+	}()
+
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case inCh <- &stubPayload{id: i}:
+			case <-done:
+				return
+			}
+		}
+	}()
+	go func() {
+		for {
+			select {
+			case <-outCh:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("dynamic worker pool did not stop after a payload failed")
+	}
+	if err := <-errCh; err == nil {
+		t.Error("expected the stage error")
+	}
+}
+
+var _ payloads.Payload = new(stubPayload)
+
+type stubPayload struct {
+	id int
+}
+
+// Clone implements payloads.Payload
+func (s *stubPayload) Clone() payloads.Payload {
+	return &stubPayload{id: s.id}
+}
+
+// MarkAsProcessed implements payloads.Payload
+func (*stubPayload) MarkAsProcessed() {}
+
+// Out implements payloads.Payload
+func (*stubPayload) Out() (string, string, []byte) {
+	return "", "", nil
+}
+
+// pipelines_test 패키지에서 테스트용으로만 사용하는 StageParams 구현체
+type stageParams struct {
+	stage int
+	inCh  <-chan payloads.Payload
+	outCh []chan<- payloads.Payload
+	errCh chan<- error
+}
+
+func (p *stageParams) StageIndex() int                   { return p.stage }
+func (p *stageParams) Input() <-chan payloads.Payload    { return p.inCh }
+func (p *stageParams) Output() []chan<- payloads.Payload { return p.outCh }
+func (p *stageParams) Error() chan<- error               { return p.errCh }
@@ -0,0 +1,167 @@
+package pipelines
+
+import (
+	"context"
+	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/payloads"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 스테이지나 스토리지에서 에러가 발생했을 때의 처리 방법
+const (
+	// 에러를 보고하고 파이프라인을 멈춘다.
+	ON_ERROR_HALT = "halt"
+	// 실패한 페이로드를 버리고 계속 진행한다.
+	ON_ERROR_SKIP = "skip"
+	// 백오프 후 다시 시도하고, 모두 실패하면 Fallback 을 따른다.
+	ON_ERROR_RETRY = "retry"
+	// 실패한 페이로드를 데드레터 싱크로 보낸다.
+	ON_ERROR_DEAD_LETTER = "dead_letter"
+	// 실패를 소스에 알리고(nack) 파이프라인은 계속 진행한다.
+	ON_ERROR_NACK = "nack"
+
+	DEFAULT_RETRY_MAX_ATTEMPTS = 3
+	DEFAULT_RETRY_BACKOFF      = 100 * time.Millisecond
+	DEFAULT_RETRY_MAX_BACKOFF  = 10 * time.Second
+)
+
+// ErrorPolicy decides what happens to a payload that fails a stage or a
+// storage. A nil ErrorPolicy halts the pipeline, or sends the payload to the
+// dead letter sink when the pipeline has one. Sources cannot all redeliver a
+// nacked payload, e.g. Kafka only re-reads it after a restart, so nack has to
+// be chosen explicitly.
+type ErrorPolicy struct {
+	Action string
+
+	// retry 설정: 첫 시도를 포함한 최대 시도 횟수와 지수 백오프
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	// 재시도가 모두 실패했을 때의 처리 방법 (halt, skip, nack, dead_letter)
+	Fallback string
+}
+
+// Validate checks the policy and fills in the retry defaults.
+func (e *ErrorPolicy) Validate() error {
+	if e == nil {
+		return nil
+	}
+	switch e.Action {
+	case ON_ERROR_HALT, ON_ERROR_SKIP, ON_ERROR_NACK, ON_ERROR_DEAD_LETTER:
+		return nil
+	case ON_ERROR_RETRY:
+	default:
+		return fmt.Errorf("invalid on_error policy %q. Must be one of: %s, %s, %s, %s, %s", e.Action, ON_ERROR_HALT, ON_ERROR_SKIP, ON_ERROR_NACK, ON_ERROR_RETRY, ON_ERROR_DEAD_LETTER)
+	}
+	if e.MaxAttempts <= 0 {
+		e.MaxAttempts = DEFAULT_RETRY_MAX_ATTEMPTS
+	}
+	if e.Backoff <= 0 {
+		e.Backoff = DEFAULT_RETRY_BACKOFF
+	}
+	if e.MaxBackoff <= 0 {
+		e.MaxBackoff = DEFAULT_RETRY_MAX_BACKOFF
+	}
+	switch e.Fallback {
+	case "":
+		e.Fallback = ON_ERROR_HALT
+	case ON_ERROR_HALT, ON_ERROR_SKIP, ON_ERROR_NACK, ON_ERROR_DEAD_LETTER:
+	default:
+		return fmt.Errorf("invalid on_error fallback %q. Must be one of: %s, %s, %s, %s", e.Fallback, ON_ERROR_HALT, ON_ERROR_SKIP, ON_ERROR_NACK, ON_ERROR_DEAD_LETTER)
+	}
+	return nil
+}
+
+// Uses reports whether the policy ends up with the given action.
+func (e *ErrorPolicy) Uses(action string) bool {
+	return e != nil && (e.Action == action || (e.Action == ON_ERROR_RETRY && e.Fallback == action))
+}
+
+// BackoffOf returns how long to wait after the attempt-th failure.
+func (e *ErrorPolicy) BackoffOf(attempt int) time.Duration {
+	backoff := e.Backoff
+	for i := 1; i < attempt && backoff < e.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > e.MaxBackoff {
+		backoff = e.MaxBackoff
+	}
+	return backoff
+}
+
+// errorHandler applies the error policy of one stage or storage.
+type errorHandler struct {
+	stage      int
+	policy     *ErrorPolicy
+	deadLetter func(dl *payloads.DeadLetter) bool
+	errCh      chan<- error
+
+	// 스토리지가 비동기로 보고한 실패를 처리 중인 고루틴
+	settling *sync.WaitGroup
+}
+
+// retry waits for the backoff after the attempt-th failure and reports
+// whether the payload should be tried again.
+func (h *errorHandler) retry(ctx context.Context, attempt int) bool {
+	if h.policy == nil || h.policy.Action != ON_ERROR_RETRY || attempt >= h.policy.MaxAttempts {
+		return false
+	}
+	h.count(ON_ERROR_RETRY)
+	select {
+	case <-time.After(h.policy.BackoffOf(attempt)):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// handle settles a payload that failed for good and reports whether the
+// stage can keep running.
+func (h *errorHandler) handle(p payloads.Payload, err error) bool {
+	action := h.action()
+	switch action {
+	case ON_ERROR_SKIP:
+		h.count(action)
+		logger.Warnf("stage %d: skipping payload: %v", h.stage, err)
+		// 버린 페이로드도 처리가 끝난 것으로 보고 소스에 알린다.
+		payloads.Acknowledge(p)
+		p.MarkAsProcessed()
+		return true
+	case ON_ERROR_NACK:
+		h.count(action)
+		logger.Errorf("stage %d: payload not processed: %v", h.stage, err)
+		// 소스가 실패한 메시지를 다시 받을지 결정하도록 실패를 알린다.
+		payloads.Reject(p, err)
+		p.MarkAsProcessed()
+		return true
+	case ON_ERROR_DEAD_LETTER:
+		if h.deadLetter != nil && h.deadLetter(payloads.NewDeadLetter(p, err, h.stage)) {
+			h.count(action)
+			return true
+		}
+	}
+	h.count(ON_ERROR_HALT)
+	payloads.Reject(p, err)
+	maybeEmitError(err, h.errCh)
+	return false
+}
+
+// action returns what to do once retries, if any, are exhausted.
+func (h *errorHandler) action() string {
+	switch {
+	case h.policy == nil && h.deadLetter != nil:
+		return ON_ERROR_DEAD_LETTER
+	case h.policy == nil:
+		return ON_ERROR_HALT
+	case h.policy.Action == ON_ERROR_RETRY:
+		return h.policy.Fallback
+	}
+	return h.policy.Action
+}
+
+func (h *errorHandler) count(action string) {
+	errorPolicyTotal.WithLabelValues(strconv.Itoa(h.stage), action).Inc()
+}
@@ -0,0 +1,59 @@
+package pipelines_test
+
+import (
+	"event-data-pipeline/pkg/pipelines"
+	"testing"
+	"time"
+)
+
+func TestErrorPolicy_Validate(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		policy pipelines.ErrorPolicy
+		valid  bool
+	}{
+		{desc: "halt", policy: pipelines.ErrorPolicy{Action: pipelines.ON_ERROR_HALT}, valid: true},
+		{desc: "skip", policy: pipelines.ErrorPolicy{Action: pipelines.ON_ERROR_SKIP}, valid: true},
+		{desc: "nack", policy: pipelines.ErrorPolicy{Action: pipelines.ON_ERROR_NACK}, valid: true},
+		{desc: "retry then nack", policy: pipelines.ErrorPolicy{Action: pipelines.ON_ERROR_RETRY, Fallback: pipelines.ON_ERROR_NACK}, valid: true},
+		{desc: "retry with defaults", policy: pipelines.ErrorPolicy{Action: pipelines.ON_ERROR_RETRY}, valid: true},
+		{desc: "retry then dead letter", policy: pipelines.ErrorPolicy{Action: pipelines.ON_ERROR_RETRY, Fallback: pipelines.ON_ERROR_DEAD_LETTER}, valid: true},
+		{desc: "retry then retry", policy: pipelines.ErrorPolicy{Action: pipelines.ON_ERROR_RETRY, Fallback: pipelines.ON_ERROR_RETRY}, valid: false},
+		{desc: "unknown policy", policy: pipelines.ErrorPolicy{Action: "ignore"}, valid: false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			err := tC.policy.Validate()
+			if (err == nil) != tC.valid {
+				t.Errorf("expected valid: %v, got %v", tC.valid, err)
+			}
+		})
+	}
+
+	policy := pipelines.ErrorPolicy{Action: pipelines.ON_ERROR_RETRY}
+	policy.Validate()
+	if policy.MaxAttempts != pipelines.DEFAULT_RETRY_MAX_ATTEMPTS || policy.Fallback != pipelines.ON_ERROR_HALT {
+		t.Errorf("expected retry defaults, got %+v", policy)
+	}
+}
+
+func TestErrorPolicy_BackoffOf(t *testing.T) {
+	policy := pipelines.ErrorPolicy{
+		Action:     pipelines.ON_ERROR_RETRY,
+		Backoff:    100 * time.Millisecond,
+		MaxBackoff: time.Second,
+	}
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, backoff := range expected {
+		if got := policy.BackoffOf(i + 1); got != backoff {
+			t.Errorf("attempt %d: expected %v, got %v", i+1, backoff, got)
+		}
+	}
+}
@@ -3,15 +3,26 @@ package pipelines
 import (
 	"context"
 	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/payloads"
 	"event-data-pipeline/pkg/processors"
 
 	"golang.org/x/xerrors"
 )
 
+func init() {
+	Register("fifo", NewFIFO)
+}
+
 type fifo struct {
 	proc processors.Processor
 }
 
+// NewFIFO is the StageRunnerFactory for FIFO. The fifo runner takes no
+// configuration.
+func NewFIFO(proc processors.Processor, config jsonObj) (StageRunner, error) {
+	return FIFO(proc), nil
+}
+
 // FIFO returns a StageRunner that processes incoming payloads in a first-in
 // first-out fashion. Each input is passed to the specified processor and its
 // output is emitted to the next stage.
@@ -36,36 +47,69 @@ func (r fifo) Run(ctx context.Context, params StageParams) {
 			if payloadIn == nil { // payloadIn가 닐이면 리턴을 한다
 				return
 			}
-			// 이 로직을 통과하면 payloadIn에서 복사를 한다. => 디 카피를 해서 하나의 복사본을 만든다.
-			clone := payloadIn.Clone()
-
-			payloadOut, err := r.proc.Process(ctx, clone) // 실제 프로세서를 실행하는 로직은 여기다. 주입한 프로세서가 된다. 주입하는 과정은 fifo StageRunner 객체를 생성할 때 생성된 프로세서 객체를 넣어줬다. 그 안에 들어간 프로세서를 실행하게 되는것이다.
-			// payloadOut 실행 결과에 따라서
-			if err != nil { // 에러가 있으면 출력 처리를 한다.
-				wrappedErr := xerrors.Errorf("pipeline stage %d: %w", params.StageIndex(), err)
-				maybeEmitError(wrappedErr, params.Error())
+			if !r.process(ctx, params, payloadIn) {
 				return
 			}
+		}
+	}
+}
 
-			// 에러가 없으면
-			// If the processor did not output a payload for the
-			// next stage there is nothing we need to do.
-			if payloadOut == nil { // payloadOut 결과값이 없으면 그 다음으로 넘어간다.
-				payloadIn.MarkAsProcessed() // 처리가 끝나서 필요없는 경우가 있다. 리턴된 페이로드가 없을 때 더이상 진행할 필요가 없으니까 그 다음 프로세서에 넘길 필요는 경우가 발생해서 이 코드를 선언해놨다.
-				continue
-			}
+// process runs the processor on a single input payload and emits its output
+// to the next stage. It returns false when the stage has to stop.
+func (r fifo) process(ctx context.Context, params StageParams, payloadIn payloads.Payload) bool {
+	handler, _ := params.(ErrorHandler)
 
-			// 결과값이 있으면 payloadOut를 Output으로 넘긴다. =>
-			// broadcast output to all output channels
-			for _, outCh := range params.Output() { // 컨슈머는 아웃을 해서 채널에 넣고 프로세스는 채널안에 데이터를 in한다. 프로세스는 out 해서 채널에 널고 스토리지 프로바이저는 데이터를 in한다.
-				// 프로세서가 output으로 데이터를 넘겨준다.
-				p := payloadOut.Clone()
-				select {
-				case outCh <- p:
-				case <-ctx.Done():
-					return
-				}
-			}
+	var payloadOut payloads.Payload
+	var err error
+	for attempt := 1; ; attempt++ {
+		// 이 로직을 통과하면 payloadIn에서 복사를 한다. => 디 카피를 해서 하나의 복사본을 만든다.
+		clone := payloadIn.Clone()
+
+		payloadOut, err = r.proc.Process(ctx, clone) // 실제 프로세서를 실행하는 로직은 여기다. 주입한 프로세서가 된다. 주입하는 과정은 fifo StageRunner 객체를 생성할 때 생성된 프로세서 객체를 넣어줬다. 그 안에 들어간 프로세서를 실행하게 되는것이다.
+		// 재시도 정책이 있으면 백오프 후 원본에서 다시 복사해서 처리
+		if err == nil || handler == nil || !handler.Retry(ctx, attempt) {
+			break
+		}
+	}
+	// payloadOut 실행 결과에 따라서
+	if err != nil { // 에러가 있으면 출력 처리를 한다.
+		wrappedErr := xerrors.Errorf("pipeline stage %d: %w", params.StageIndex(), err)
+		// 스테이지의 에러 정책에 따라 건너뛰거나 데드레터로 보내고 계속 진행
+		if handler != nil {
+			return handler.HandleError(payloadIn, wrappedErr)
+		}
+		payloads.Reject(payloadIn, wrappedErr)
+		maybeEmitError(wrappedErr, params.Error())
+		return false
+	}
+
+	// 에러가 없으면
+	// If the processor did not output a payload for the
+	// next stage there is nothing we need to do.
+	if payloadOut == nil { // payloadOut 결과값이 없으면 그 다음으로 넘어간다.
+		payloads.Acknowledge(payloadIn)
+		payloadIn.MarkAsProcessed() // 처리가 끝나서 필요없는 경우가 있다. 리턴된 페이로드가 없을 때 더이상 진행할 필요가 없으니까 그 다음 프로세서에 넘길 필요는 경우가 발생해서 이 코드를 선언해놨다.
+		return true
+	}
+
+	// 결과값이 있으면 payloadOut를 Output으로 넘긴다. =>
+	// 여러 출력 채널로 보내는 경우 모든 출력이 처리되어야 전달이 완료된다.
+	batch, ok := payloadOut.(payloads.Batch)
+	if !ok {
+		return emit(ctx, payloadOut, params.Output())
+	}
+
+	// 여러 페이로드로 나뉜 경우 각 페이로드를 따로 넘기고, 모두 처리되어야 원본의 전달이 완료된다.
+	if len(batch) == 0 {
+		payloads.Acknowledge(payloadIn)
+		payloadIn.MarkAsProcessed()
+		return true
+	}
+	payloads.Fanout(batch[0], len(batch)-1)
+	for _, p := range batch {
+		if !emit(ctx, p, params.Output()) {
+			return false
 		}
 	}
+	return true
 }
@@ -6,10 +6,34 @@ import (
 	"sync"
 )
 
+func init() {
+	Register("fixed_pool", NewFixedWorkerPool)
+}
+
+// FixedPoolCfg is the runner config of the fixed_pool StageRunner.
+type FixedPoolCfg struct {
+	Workers int `json:"workers,omitempty"`
+}
+
 type fixedWorkerPool struct {
 	fifos []StageRunner
 }
 
+// NewFixedWorkerPool is the StageRunnerFactory for FixedWorkerPool.
+// It falls back to a single worker when workers is not configured.
+func NewFixedWorkerPool(proc processors.Processor, config jsonObj) (StageRunner, error) {
+	var cfg FixedPoolCfg
+	if err := loadRunnerConfig(config, &cfg); err != nil {
+		return nil, err
+	}
+
+	numWorkers := 1
+	if cfg.Workers > 0 {
+		numWorkers = cfg.Workers
+	}
+	return FixedWorkerPool(proc, numWorkers), nil
+}
+
 // FixedWorkerPool returns a StageRunner that spins up a pool containing
 // numWorkers to process incoming payloads in parallel and emit their outputs
 // to the next stage.
@@ -0,0 +1,197 @@
+package pipelines
+
+import (
+	"context"
+	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/processors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	DEFAULT_PAYLOAD_KEY     = "key"
+	DEFAULT_KEYED_QUEUE_LEN = 16
+	VALUE_KEY_PREFIX        = "value."
+)
+
+func init() {
+	Register("keyed_pool", NewKeyedWorkerPool)
+}
+
+// KeyedPoolCfg is the runner config of the keyed_pool StageRunner.
+//
+// Key selects what payloads are partitioned by:
+//   - key       : the Kafka message key
+//   - partition : the Kafka partition
+//   - topic     : the Kafka topic
+//   - queue     : the RabbitMQ queue
+//   - value.<field> : a field of the payload value, e.g. value.user.id
+type KeyedPoolCfg struct {
+	Workers   int    `json:"workers,omitempty"`
+	Key       string `json:"key,omitempty"`
+	QueueSize int    `json:"queue_size,omitempty"`
+}
+
+type keyedWorkerPool struct {
+	proc       processors.Processor
+	numWorkers int
+	key        string
+	queueSize  int
+}
+
+// NewKeyedWorkerPool is the StageRunnerFactory for KeyedWorkerPool.
+func NewKeyedWorkerPool(proc processors.Processor, config jsonObj) (StageRunner, error) {
+	var cfg KeyedPoolCfg
+	if err := loadRunnerConfig(config, &cfg); err != nil {
+		return nil, err
+	}
+
+	numWorkers := 1
+	if cfg.Workers > 0 {
+		numWorkers = cfg.Workers
+	}
+	key := DEFAULT_PAYLOAD_KEY
+	if cfg.Key != "" {
+		key = cfg.Key
+	}
+	pool := KeyedWorkerPool(proc, numWorkers, key).(*keyedWorkerPool)
+	if cfg.QueueSize > 0 {
+		pool.queueSize = cfg.QueueSize
+	}
+	return pool, nil
+}
+
+// KeyedWorkerPool returns a StageRunner that processes incoming payloads in
+// parallel on numWorkers workers while keeping the order of payloads that
+// share the same key. Each payload is hashed by its key to a single worker,
+// so ordering holds per key but not across keys.
+func KeyedWorkerPool(proc processors.Processor, numWorkers int, key string) StageRunner {
+	if numWorkers <= 0 {
+		panic("KeyedWorkerPool: numWorkers must be > 0")
+	}
+	return &keyedWorkerPool{
+		proc:       proc,
+		numWorkers: numWorkers,
+		key:        key,
+		queueSize:  DEFAULT_KEYED_QUEUE_LEN,
+	}
+}
+
+// Run implements StageRunner.
+func (p *keyedWorkerPool) Run(ctx context.Context, params StageParams) {
+	ctx, cancelFn := context.WithCancel(ctx)
+	defer cancelFn()
+
+	runner := fifo{proc: p.proc}
+
+	// 워커마다 자신의 입력 큐를 가진다.
+	queues := make([]chan payloads.Payload, p.numWorkers)
+	var wg sync.WaitGroup
+	for i := 0; i < p.numWorkers; i++ {
+		queues[i] = make(chan payloads.Payload, p.queueSize)
+		wg.Add(1)
+		go func(queue <-chan payloads.Payload) {
+			defer wg.Done()
+			for payloadIn := range queue {
+				if !runner.process(ctx, params, payloadIn) {
+					// 한 키의 순서가 깨지지 않도록 스테이지 전체를 멈춘다.
+					cancelFn()
+					return
+				}
+			}
+		}(queues[i])
+	}
+
+	p.dispatch(ctx, params.Input(), queues)
+
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+	logger.Debugf("shutting down keyed worker pool run...")
+}
+
+// dispatch hashes every input payload to the queue of its worker until the
+// input is closed or the context is cancelled.
+func (p *keyedWorkerPool) dispatch(ctx context.Context, inCh <-chan payloads.Payload, queues []chan payloads.Payload) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case payloadIn, ok := <-inCh:
+			if !ok || payloadIn == nil {
+				return
+			}
+			key, found := PayloadKey(payloadIn, p.key)
+			if !found {
+				logger.Debugf("payload has no %s key, dispatching with an empty key", p.key)
+			}
+			select {
+			case queues[hashKey(key, len(queues))] <- payloadIn:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// hashKey maps a key to one of n workers.
+func hashKey(key string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
+
+// PayloadKey returns the partitioning key of the payload selected by name.
+// See KeyedPoolCfg for the supported names.
+func PayloadKey(p payloads.Payload, name string) (string, bool) {
+	e, err := payloads.AsEvent(p)
+	if err != nil {
+		return "", false
+	}
+	switch name {
+	case "key":
+		return e.Key, e.Key != ""
+	case "partition":
+		return strconv.FormatInt(int64(e.Source.Partition), 10), e.Source.Type == payloads.SOURCE_KAFKA
+	case "topic":
+		return e.Source.Topic, e.Source.Topic != ""
+	case "queue":
+		return e.Source.Queue, e.Source.Queue != ""
+	}
+
+	if !strings.HasPrefix(name, VALUE_KEY_PREFIX) {
+		return "", false
+	}
+	return lookupValue(e.Value, strings.TrimPrefix(name, VALUE_KEY_PREFIX))
+}
+
+// lookupValue walks a dotted path through nested value objects.
+func lookupValue(value map[string]interface{}, path string) (string, bool) {
+	fields := strings.Split(path, ".")
+	var cur interface{} = value
+	for _, field := range fields {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		cur, ok = obj[field]
+		if !ok {
+			return "", false
+		}
+	}
+	switch v := cur.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
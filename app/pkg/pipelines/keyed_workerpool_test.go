@@ -0,0 +1,91 @@
+package pipelines_test
+
+import (
+	"context"
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/pipelines"
+	"event-data-pipeline/pkg/processors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestKeyedWorkerPool_Run(t *testing.T) {
+	// 처리 시간을 무작위로 주어 키가 다른 페이로드끼리는 순서가 섞이도록 한다.
+	jitter := processors.ProcessorFunc(func(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+		time.Sleep(time.Duration(rand.Intn(2000)) * time.Microsecond)
+		return p, nil
+	})
+
+	pool := pipelines.KeyedWorkerPool(jitter, 4, "key")
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFunc()
+
+	inCh := make(chan payloads.Payload)
+	outCh := make(chan payloads.Payload)
+	errCh := make(chan error, 1)
+	params := &stageParams{stage: 0, inCh: inCh, outCh: []chan<- payloads.Payload{outCh}, errCh: errCh}
+
+	go pool.Run(ctx, params)
+
+	keys := []string{"a", "b", "c", "d", "e", "f"}
+	perKey := 30
+	go func() {
+		for seq := 0; seq < perKey; seq++ {
+			for _, key := range keys {
+				inCh <- &payloads.KafkaPayload{Key: key, Value: map[string]interface{}{"seq": float64(seq)}}
+			}
+		}
+		close(inCh)
+	}()
+
+	next := make(map[string]float64)
+	for received := 0; received < perKey*len(keys); received++ {
+		select {
+		case out := <-outCh:
+			kp := out.(*payloads.KafkaPayload)
+			seq := kp.Value["seq"].(float64)
+			if seq != next[kp.Key] {
+				t.Fatalf("key %s: expected seq %v, got %v", kp.Key, next[kp.Key], seq)
+			}
+			next[kp.Key]++
+		case err := <-errCh:
+			t.Fatal(err)
+		case <-ctx.Done():
+			t.Fatalf("received %d payloads before timeout", received)
+		}
+	}
+}
+
+func TestPayloadKey(t *testing.T) {
+	kp := &payloads.KafkaPayload{
+		Topic:     "purchases",
+		Partition: 3,
+		Key:       "user-1",
+		Value: map[string]interface{}{
+			"user": map[string]interface{}{"id": float64(42)},
+		},
+	}
+	testCases := []struct {
+		desc  string
+		name  string
+		key   string
+		found bool
+	}{
+		{desc: "message key", name: "key", key: "user-1", found: true},
+		{desc: "partition", name: "partition", key: "3", found: true},
+		{desc: "topic", name: "topic", key: "purchases", found: true},
+		{desc: "nested value field", name: "value.user.id", key: "42", found: true},
+		{desc: "missing value field", name: "value.user.email", key: "", found: false},
+		{desc: "unknown key", name: "queue", key: "", found: false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			key, found := pipelines.PayloadKey(kp, tC.name)
+			if key != tC.key || found != tC.found {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tC.key, tC.found, key, found)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package pipelines
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	EDP_PIPELINE_DYNAMIC_POOL_WORKERS      = "edp_pipeline_dynamic_pool_workers"
+	EDP_PIPELINE_DYNAMIC_POOL_WORKERS_HELP = "the number of workers currently running in a dynamic worker pool stage"
+
+	EDP_PIPELINE_DEAD_LETTER_TOTAL      = "edp_pipeline_dead_letter_total"
+	EDP_PIPELINE_DEAD_LETTER_TOTAL_HELP = "the number of payloads sent to the dead letter sink in total"
+
+	EDP_PIPELINE_ERROR_POLICY_TOTAL      = "edp_pipeline_error_policy_total"
+	EDP_PIPELINE_ERROR_POLICY_TOTAL_HELP = "the number of error policy decisions made for failed payloads in total"
+
+	EDP_PIPELINE_DROPPED_ERRORS_TOTAL      = "edp_pipeline_dropped_errors_total"
+	EDP_PIPELINE_DROPPED_ERRORS_TOTAL_HELP = "the number of errors dropped because the error channel was full in total"
+
+	EDP_PIPELINE_ROUTE_SKIPPED_TOTAL      = "edp_pipeline_route_skipped_total"
+	EDP_PIPELINE_ROUTE_SKIPPED_TOTAL_HELP = "the number of payloads a storage skipped because its route did not match in total"
+)
+
+var (
+	dynamicPoolWorkers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: EDP_PIPELINE_DYNAMIC_POOL_WORKERS,
+		Help: EDP_PIPELINE_DYNAMIC_POOL_WORKERS_HELP},
+		[]string{"stage"},
+	)
+	deadLetterTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: EDP_PIPELINE_DEAD_LETTER_TOTAL,
+		Help: EDP_PIPELINE_DEAD_LETTER_TOTAL_HELP},
+		[]string{"stage"},
+	)
+	errorPolicyTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: EDP_PIPELINE_ERROR_POLICY_TOTAL,
+		Help: EDP_PIPELINE_ERROR_POLICY_TOTAL_HELP},
+		[]string{"stage", "action"},
+	)
+	droppedErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: EDP_PIPELINE_DROPPED_ERRORS_TOTAL,
+		Help: EDP_PIPELINE_DROPPED_ERRORS_TOTAL_HELP},
+	)
+	routeSkippedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: EDP_PIPELINE_ROUTE_SKIPPED_TOTAL,
+		Help: EDP_PIPELINE_ROUTE_SKIPPED_TOTAL_HELP},
+		[]string{"stage"},
+	)
+)
+
+func init() {
+	prometheus.Register(dynamicPoolWorkers)
+	prometheus.Register(deadLetterTotal)
+	prometheus.Register(errorPolicyTotal)
+	prometheus.Register(droppedErrorsTotal)
+	prometheus.Register(routeSkippedTotal)
+}
@@ -6,6 +6,7 @@ import (
 	"event-data-pipeline/pkg/payloads"
 	"event-data-pipeline/pkg/sources"
 	"event-data-pipeline/pkg/storage_providers"
+	"strconv"
 
 	"sync"
 
@@ -15,6 +16,20 @@ import (
 
 type Pipeline struct {
 	stages []StageRunner
+
+	// 스테이지와 스토리지 별 입력 노드, 없으면 선형 파이프라인
+	stageInputs   [][]int
+	storageInputs [][]int
+
+	// 처리나 저장에 실패한 페이로드를 받는 싱크, 없으면 실패 시 파이프라인을 멈춘다.
+	deadLetter Sink
+
+	// 스테이지와 스토리지 별 에러 처리 정책, 없으면 기본 정책
+	stagePolicies   []*ErrorPolicy
+	storagePolicies []*ErrorPolicy
+
+	// 스토리지 별 라우팅 규칙, 없으면 모든 페이로드를 받는다.
+	routes []*Route
 }
 
 // New returns a new pipeline instance where input payloads will traverse each
@@ -25,6 +40,43 @@ func New(stages ...StageRunner) *Pipeline {
 	}
 }
 
+// SetDeadLetterSink sets the storage provider that receives payloads failing
+// a stage or a storage. With a dead letter sink, such failures no longer stop
+// the pipeline.
+func (p *Pipeline) SetDeadLetterSink(s storage_providers.StorageProvider) {
+	p.deadLetter = s.(Sink)
+}
+
+// SetErrorPolicies sets the error policy of each stage and each storage, in
+// the order they are passed to New and Process. A nil policy falls back to the
+// default one.
+func (p *Pipeline) SetErrorPolicies(stages, storages []*ErrorPolicy) {
+	p.stagePolicies = stages
+	p.storagePolicies = storages
+}
+
+// SetRoutes sets the route of each storage, in the order they are passed to
+// Process. A storage without a route receives every payload.
+func (p *Pipeline) SetRoutes(routes []*Route) {
+	p.routes = routes
+}
+
+// routeAt returns the idx-th route, or nil when it was not set.
+func routeAt(routes []*Route, idx int) *Route {
+	if idx < len(routes) {
+		return routes[idx]
+	}
+	return nil
+}
+
+// policyAt returns the idx-th policy, or nil when it was not set.
+func policyAt(policies []*ErrorPolicy, idx int) *ErrorPolicy {
+	if idx < len(policies) {
+		return policies[idx]
+	}
+	return nil
+}
+
 // Process reads the contents of the specified source, sends them through the
 // various stages of the pipeline and directs the results to the specified sink
 // and returns back any errors that may have occurred.
@@ -38,34 +90,66 @@ func New(stages ...StageRunner) *Pipeline {
 func (p *Pipeline) Process(wg *sync.WaitGroup, ctx context.Context, source sources.Source, storageProviders []storage_providers.StorageProvider, errCh chan error) error {
 	pCtx, ctxCancelFn := context.WithCancel(ctx)
 
-	// Allocate channels for wiring together the source, the pipeline stages
-	// and the output sink. The output of the i_th stage is used as an input
-	// for the i+1_th stage. We need to allocate one extra channel than the
-	// number of stages so we can also wire the source/sink.
-	stageCh := make([]chan payloads.Payload, len(p.stages)+len(storageProviders))
-	for i := 0; i < len(stageCh); i++ {
+	var deadLetter func(dl *payloads.DeadLetter) bool
+	if p.deadLetter != nil {
+		deadLetter = func(dl *payloads.DeadLetter) bool {
+			return p.sendToDeadLetter(pCtx, dl)
+		}
+	}
+
+	// Allocate one input channel for each stage and each storage. Every
+	// producer (the source or a stage) writes to the input channels of the
+	// stages and storages reading from it, and an input channel is closed
+	// once all of its producers are done.
+	stageCh := make([]chan payloads.Payload, len(p.stages))
+	sinkCh := make([]chan payloads.Payload, len(storageProviders))
+	outChs := make(map[int][]chan<- payloads.Payload)
+	doneFns := make(map[int][]func())
+	connect := func(inCh chan payloads.Payload, inputs []int) {
+		var producers sync.WaitGroup
+		producers.Add(len(inputs))
+		for _, input := range inputs {
+			outChs[input] = append(outChs[input], inCh)
+			doneFns[input] = append(doneFns[input], producers.Done)
+		}
+		go func() {
+			producers.Wait()
+			// Signal the consumer that no more data is available.
+			close(inCh)
+		}()
+	}
+	for i := range p.stages {
 		stageCh[i] = make(chan payloads.Payload)
+		connect(stageCh[i], p.inputsOf(i))
+	}
+	for i := range storageProviders {
+		sinkCh[i] = make(chan payloads.Payload)
+		connect(sinkCh[i], p.storageInputsOf(i))
+	}
+	producerDone := func(node int) {
+		for _, done := range doneFns[node] {
+			done()
+		}
 	}
 
 	// Start a worker for each stage
 	for i := 0; i < len(p.stages); i++ {
 		wg.Add(1)
 		go func(stageIndex int) {
-			outCh := []chan<- payloads.Payload{stageCh[stageIndex+1]}
-			if stageIndex == len(p.stages)-1 {
-				outCh = make([]chan<- payloads.Payload, 0)
-				for i := len(p.stages); i < len(p.stages)+len(storageProviders); i++ {
-					outCh = append(outCh, stageCh[i])
-				}
-			}
 			p.stages[stageIndex].Run(pCtx, &workerParams{
 				stage: stageIndex,
 				inCh:  stageCh[stageIndex],
-				outCh: outCh,
+				outCh: outChs[stageIndex],
 				errCh: errCh,
+
+				handler: &errorHandler{
+					stage:      stageIndex,
+					policy:     policyAt(p.stagePolicies, stageIndex),
+					deadLetter: deadLetter,
+					errCh:      errCh,
+				},
 			})
-			// Signal next stage that no more data is available.
-			close(stageCh[stageIndex+1])
+			producerDone(stageIndex)
 			wg.Done()
 		}(i)
 	}
@@ -73,17 +157,28 @@ func (p *Pipeline) Process(wg *sync.WaitGroup, ctx context.Context, source sourc
 	// Start source and sink workers
 	wg.Add(1)
 	go func() {
-		sourceWorker(pCtx, source, stageCh[0], errCh)
-		// Signal next stage that no more data is available.
-		close(stageCh[0])
+		sourceWorker(pCtx, source, outChs[sourceNode], errCh)
+		producerDone(sourceNode)
 		wg.Done()
 	}()
 
+	// 스토리지가 Ack 로 보고한 실패는 별도의 고루틴에서 처리되므로 싱크를 닫은
+	// 뒤에도 모두 끝날 때까지 기다린다.
+	var settling sync.WaitGroup
 	for i, s := range storageProviders {
 		wg.Add(1)
 		go func(idx int, s storage_providers.StorageProvider) {
 			sink := s.(Sink)
-			sinkWorker(pCtx, sink, stageCh[len(stageCh)-1-idx], errCh)
+			handler := &errorHandler{
+				stage:      len(p.stages) + idx,
+				policy:     policyAt(p.storagePolicies, idx),
+				deadLetter: deadLetter,
+				errCh:      errCh,
+				settling:   &settling,
+			}
+			sinkWorker(pCtx, sink, sinkCh[idx], routeAt(p.routes, idx), handler)
+			// 버퍼에 남은 페이로드를 쓴다.
+			closeSink(sink, errCh)
 			wg.Done()
 		}(i, s)
 	}
@@ -92,6 +187,10 @@ func (p *Pipeline) Process(wg *sync.WaitGroup, ctx context.Context, source sourc
 	go func() {
 		logger.Debugf("start waiting")
 		wg.Wait()
+		settling.Wait()
+		if p.deadLetter != nil {
+			closeSink(p.deadLetter, errCh)
+		}
 		close(errCh)
 		ctxCancelFn()
 		logger.Debugf("Done")
@@ -108,9 +207,9 @@ func (p *Pipeline) Process(wg *sync.WaitGroup, ctx context.Context, source sourc
 }
 
 // sourceWorker implements a worker that reads Payload instances from a Source
-// and pushes them to an output channel that is used as input for the first
-// stage of the pipeline.
-func sourceWorker(ctx context.Context, source sources.Source, outCh chan<- payloads.Payload, errCh chan<- error) {
+// and pushes them to the output channels that are used as input for the
+// stages reading from the source.
+func sourceWorker(ctx context.Context, source sources.Source, outChs []chan<- payloads.Payload, errCh chan<- error) {
 out:
 	for {
 		select {
@@ -119,7 +218,9 @@ out:
 		default:
 			if source.Next(ctx) {
 				payload := source.Payload()
-				outCh <- payload
+				if !emit(ctx, payload, outChs) {
+					break out
+				}
 			}
 
 		}
@@ -132,20 +233,74 @@ out:
 	}
 }
 
+// emit sends a payload to every output channel. With several outputs, each
+// one gets its own copy and the payload is acknowledged once all of them are
+// done. It returns false when the context expires.
+func emit(ctx context.Context, payload payloads.Payload, outChs []chan<- payloads.Payload) bool {
+	switch len(outChs) {
+	case 0:
+		payloads.Acknowledge(payload)
+		return true
+	case 1:
+		select {
+		case outChs[0] <- payload:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	payloads.Fanout(payload, len(outChs)-1)
+	for _, outCh := range outChs {
+		select {
+		case outCh <- payload.Clone():
+		case <-ctx.Done():
+			return false
+		}
+	}
+	payload.MarkAsProcessed()
+	return true
+}
+
+// sendToDeadLetter writes a dead letter to the dead letter sink.
+func (p *Pipeline) sendToDeadLetter(ctx context.Context, dl *payloads.DeadLetter) bool {
+	logger.Errorf("stage %d: sending payload to dead letter sink: %s", dl.Stage, dl.Error)
+	if err := p.deadLetter.Drain(ctx, dl); err != nil {
+		logger.Errorf("error in draining dead letter: %v", err)
+		return false
+	}
+	deadLetterTotal.WithLabelValues(strconv.Itoa(dl.Stage)).Inc()
+	return true
+}
+
 // sinkWorker implements a worker that reads Payload instances from an input
 // channel (the output of the last pipeline stage) and passes them to the
-// provided sink.
-func sinkWorker(ctx context.Context, sink Sink, inCh <-chan payloads.Payload, errCh chan<- error) {
+// provided sink. Payloads the route of the sink does not match are skipped,
+// and payloads the sink fails to write are settled by the error handler of the
+// storage.
+func sinkWorker(ctx context.Context, sink Sink, inCh <-chan payloads.Payload, route *Route, handler *errorHandler) {
 	for {
 		select {
 		case payload, ok := <-inCh:
 			if !ok {
 				return
 			}
+			match, err := route.Match(payload)
+			if err != nil {
+				if !handler.handle(payload, xerrors.Errorf("pipeline route: %w", err)) {
+					return
+				}
+				continue
+			}
+			// 라우팅 규칙에 맞지 않으면 쓰지 않고 이 스토리지의 몫만 Acknowledge 한다.
+			if !match {
+				routeSkippedTotal.WithLabelValues(strconv.Itoa(handler.stage)).Inc()
+				payloads.Acknowledge(payload)
+				payload.MarkAsProcessed()
+				continue
+			}
+			// 스토리지 프로바이더는 쓰기가 완료된 후 페이로드를 Acknowledge 한다.
 			clone := payload.Clone()
-			if err := sink.Drain(ctx, clone); err != nil {
-				wrappedErr := xerrors.Errorf("pipeline sink: %w", err)
-				maybeEmitError(wrappedErr, errCh)
+			if !drain(ctx, sink, clone, handler, 1) {
 				return
 			}
 			payload.MarkAsProcessed()
@@ -156,11 +311,61 @@ func sinkWorker(ctx context.Context, sink Sink, inCh <-chan payloads.Payload, er
 	}
 }
 
+// closeSink closes the sink when it is a ClosableSink.
+func closeSink(sink Sink, errCh chan<- error) {
+	closer, ok := sink.(ClosableSink)
+	if !ok {
+		return
+	}
+	if err := closer.Close(); err != nil {
+		maybeEmitError(xerrors.Errorf("pipeline sink: %w", err), errCh)
+	}
+}
+
+// drain passes a payload to the sink. It returns false when the sink worker
+// has to stop.
+//
+// Storages write asynchronously and report failures through the Ack of the
+// payload, so the Ack is intercepted to apply the error policy to them. A
+// payload to retry is drained again after the backoff.
+func drain(ctx context.Context, sink Sink, p payloads.Payload, handler *errorHandler, attempt int) bool {
+	intercepted := payloads.Intercept(p, func(parent *payloads.Ack, err error) {
+		// 스토리지가 실패를 보고한 뒤에는 페이로드를 다시 사용할 수 있다.
+		p.(payloads.Acknowledger).SetAck(parent)
+		wrappedErr := xerrors.Errorf("pipeline sink: %w", err)
+		handler.settling.Add(1)
+		go func() {
+			defer handler.settling.Done()
+			if handler.retry(ctx, attempt) {
+				drain(ctx, sink, p, handler, attempt+1)
+				return
+			}
+			handler.handle(p, wrappedErr)
+		}()
+	})
+	for {
+		err := sink.Drain(ctx, p)
+		if err == nil {
+			return true
+		}
+		if intercepted {
+			payloads.Reject(p, err)
+			return true
+		}
+		if !handler.retry(ctx, attempt) {
+			return handler.handle(p, xerrors.Errorf("pipeline sink: %w", err))
+		}
+		attempt++
+	}
+}
+
 // maybeEmitError attempts to queue err to a buffered error channel. If the
 // channel is full, the error is dropped.
 func maybeEmitError(err error, errCh chan<- error) {
 	select {
 	case errCh <- err: // error emitted.
 	default: // error channel is full with other errors.
+		droppedErrorsTotal.Inc()
+		logger.Errorf("error channel is full, dropping error: %v", err)
 	}
 }
@@ -0,0 +1,676 @@
+package pipelines_test
+
+import (
+	"context"
+	"errors"
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/pipelines"
+	"event-data-pipeline/pkg/processors"
+	"event-data-pipeline/pkg/storage_providers"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPipeline_DeadLetter(t *testing.T) {
+	// 홀수 페이로드는 처리에 실패하고, 3 의 배수는 저장에 실패한다.
+	failOdd := processors.ProcessorFunc(func(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+		if int(p.(*payloads.KafkaPayload).Offset)%2 == 1 {
+			return nil, errors.New("invalid payload")
+		}
+		return p, nil
+	})
+
+	var acked, nacked int32
+	var mu sync.Mutex
+	source := &sliceSource{}
+	for i := 0; i < 10; i++ {
+		source.items = append(source.items, &payloads.KafkaPayload{
+			Offset: float64(i),
+			Ack: payloads.NewAck(
+				func() { mu.Lock(); acked++; mu.Unlock() },
+				func(error) { mu.Lock(); nacked++; mu.Unlock() },
+			),
+		})
+	}
+
+	storage := &recordingSink{fail: func(p payloads.Payload) bool {
+		kp, ok := p.(*payloads.KafkaPayload)
+		return ok && int(kp.Offset)%3 == 0
+	}}
+	deadLetter := &recordingSink{}
+
+	p := pipelines.New(pipelines.FIFO(failOdd))
+	p.SetDeadLetterSink(deadLetter)
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, 1)
+	done := make(chan error)
+	go func() {
+		done <- p.Process(&wg, ctx, source, []storage_providers.StorageProvider{storage}, errCh)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		mu.Lock()
+		settled := acked + nacked
+		mu.Unlock()
+		if settled == 10 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d payloads settled before timeout", settled)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancelFunc()
+	if err := <-done; err != nil {
+		t.Fatalf("expected the pipeline to keep running, got %v", err)
+	}
+
+	if acked != 10 || nacked != 0 {
+		t.Errorf("expected 10 payloads acked, got %d acked and %d nacked", acked, nacked)
+	}
+	// 0, 2, 4, 6, 8 중 0, 6 은 저장에 실패
+	if n := len(storage.written); n != 3 {
+		t.Errorf("expected 3 payloads written, got %d", n)
+	}
+	stages := map[int]int{}
+	for _, p := range deadLetter.written {
+		dl := p.(*payloads.DeadLetter)
+		if dl.Error == "" || dl.Timestamp.IsZero() {
+			t.Errorf("dead letter without error or timestamp: %+v", dl)
+		}
+		stages[dl.Stage]++
+	}
+	// 스테이지 0 에서 5 개, 첫번째 스토리지(스테이지 1) 에서 2 개
+	if stages[0] != 5 || stages[1] != 2 {
+		t.Errorf("expected 5 dead letters from stage 0 and 2 from stage 1, got %v", stages)
+	}
+}
+
+func TestPipeline_ErrorPolicy(t *testing.T) {
+	// 페이로드마다 처음 두 번은 처리에 실패하고, 5 이상은 항상 실패한다.
+	var mu sync.Mutex
+	attempts := map[float64]int{}
+	flaky := processors.ProcessorFunc(func(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+		kp := p.(*payloads.KafkaPayload)
+		mu.Lock()
+		attempts[kp.Offset]++
+		n := attempts[kp.Offset]
+		mu.Unlock()
+		if n <= 2 || kp.Offset >= 5 {
+			return nil, errors.New("temporary failure")
+		}
+		return p, nil
+	})
+
+	var acked, nacked int32
+	source := &sliceSource{}
+	for i := 0; i < 8; i++ {
+		source.items = append(source.items, &payloads.KafkaPayload{
+			Offset: float64(i),
+			Ack: payloads.NewAck(
+				func() { mu.Lock(); acked++; mu.Unlock() },
+				func(error) { mu.Lock(); nacked++; mu.Unlock() },
+			),
+		})
+	}
+
+	// 저장은 페이로드마다 한 번씩 실패한다.
+	failed := map[float64]bool{}
+	storage := &recordingSink{fail: func(p payloads.Payload) bool {
+		kp := p.(*payloads.KafkaPayload)
+		mu.Lock()
+		defer mu.Unlock()
+		if failed[kp.Offset] {
+			return false
+		}
+		failed[kp.Offset] = true
+		return true
+	}}
+
+	retry := &pipelines.ErrorPolicy{Action: pipelines.ON_ERROR_RETRY, MaxAttempts: 3, Backoff: time.Millisecond, Fallback: pipelines.ON_ERROR_SKIP}
+	retry.Validate()
+
+	p := pipelines.New(pipelines.FIFO(flaky))
+	p.SetErrorPolicies([]*pipelines.ErrorPolicy{retry}, []*pipelines.ErrorPolicy{retry})
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, 1)
+	done := make(chan error)
+	go func() {
+		done <- p.Process(&wg, ctx, source, []storage_providers.StorageProvider{storage}, errCh)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		mu.Lock()
+		settled := acked + nacked
+		mu.Unlock()
+		if settled == 8 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d payloads settled before timeout", settled)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancelFunc()
+	if err := <-done; err != nil {
+		t.Fatalf("expected the pipeline to keep running, got %v", err)
+	}
+
+	// 0~4 는 재시도 후 저장되고, 5~7 은 재시도가 모두 실패해 건너뛴다.
+	if acked != 8 || nacked != 0 {
+		t.Errorf("expected 8 payloads acked, got %d acked and %d nacked", acked, nacked)
+	}
+	if n := len(storage.written); n != 5 {
+		t.Errorf("expected 5 payloads written, got %d", n)
+	}
+	if attempts[7] != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts[7])
+	}
+}
+
+func TestPipeline_StorageNack(t *testing.T) {
+	var mu sync.Mutex
+	var acked, nacked int32
+	source := &sliceSource{}
+	for i := 0; i < 6; i++ {
+		source.items = append(source.items, &payloads.KafkaPayload{
+			Offset: float64(i),
+			Ack: payloads.NewAck(
+				func() { mu.Lock(); acked++; mu.Unlock() },
+				func(error) { mu.Lock(); nacked++; mu.Unlock() },
+			),
+		})
+	}
+
+	// 홀수 오프셋은 저장에 실패한다.
+	storage := &recordingSink{fail: func(p payloads.Payload) bool {
+		return int(p.(*payloads.KafkaPayload).Offset)%2 == 1
+	}}
+
+	// nack 정책이면 스토리지 실패를 소스에 알리고 파이프라인을 멈추지 않는다.
+	p := pipelines.New()
+	p.SetErrorPolicies(nil, []*pipelines.ErrorPolicy{{Action: pipelines.ON_ERROR_NACK}})
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, 1)
+	done := make(chan error)
+	go func() {
+		done <- p.Process(&wg, ctx, source, []storage_providers.StorageProvider{storage}, errCh)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		mu.Lock()
+		settled := acked + nacked
+		mu.Unlock()
+		if settled == 6 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d payloads settled before timeout", settled)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancelFunc()
+	if err := <-done; err != nil {
+		t.Fatalf("expected the pipeline to keep running, got %v", err)
+	}
+
+	if acked != 3 || nacked != 3 {
+		t.Errorf("expected 3 payloads acked and 3 nacked, got %d acked and %d nacked", acked, nacked)
+	}
+	if n := len(storage.written); n != 3 {
+		t.Errorf("expected 3 payloads written, got %d", n)
+	}
+}
+
+func TestPipeline_StorageFailureWithoutPolicy(t *testing.T) {
+	source := &sliceSource{items: []payloads.Payload{&payloads.Event{Ack: payloads.NewAck(nil, nil)}}}
+	storage := &recordingSink{fail: func(p payloads.Payload) bool { return true }}
+
+	// 정책이 없으면 스토리지 실패도 파이프라인을 멈춘다.
+	var wg sync.WaitGroup
+	errCh := make(chan error, 1)
+	err := pipelines.New().Process(&wg, context.Background(), source, []storage_providers.StorageProvider{storage}, errCh)
+	if err == nil {
+		t.Fatal("expected the pipeline to halt")
+	}
+}
+
+func TestPipeline_StorageFailureOnClose(t *testing.T) {
+	var mu sync.Mutex
+	var nacked int
+	source := &sliceSource{}
+	for i := 0; i < 3; i++ {
+		source.items = append(source.items, &payloads.Event{
+			Ack: payloads.NewAck(nil, func(error) { mu.Lock(); nacked++; mu.Unlock() }),
+		})
+	}
+	storage := &bufferingSink{err: errors.New("flush failed")}
+
+	p := pipelines.New()
+	p.SetErrorPolicies(nil, []*pipelines.ErrorPolicy{{Action: pipelines.ON_ERROR_RETRY, MaxAttempts: 2, Backoff: time.Millisecond, MaxBackoff: time.Millisecond, Fallback: pipelines.ON_ERROR_HALT}})
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, 1)
+	done := make(chan error)
+	go func() {
+		done <- p.Process(&wg, ctx, source, []storage_providers.StorageProvider{storage}, errCh)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for storage.len() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d payloads buffered before timeout", storage.len())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 싱크를 닫을 때 보고된 실패도 errCh 를 닫기 전에 에러 정책이 처리한다.
+	cancelFunc()
+	if err := <-done; err == nil {
+		t.Fatal("expected the failures reported on close to halt the pipeline")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if nacked != 3 {
+		t.Errorf("expected 3 payloads nacked, got %d", nacked)
+	}
+}
+
+func TestPipeline_Routes(t *testing.T) {
+	var mu sync.Mutex
+	var acked, nacked int32
+	source := &sliceSource{}
+	for i := 0; i < 6; i++ {
+		topic := "purchases"
+		if i%2 == 1 {
+			topic = "users"
+		}
+		source.items = append(source.items, &payloads.Event{
+			Source: payloads.EventSource{Topic: topic, Offset: int64(i)},
+			Value:  map[string]interface{}{"refund": i%3 == 0},
+			Ack: payloads.NewAck(
+				func() { mu.Lock(); acked++; mu.Unlock() },
+				func(error) { mu.Lock(); nacked++; mu.Unlock() },
+			),
+		})
+	}
+
+	// 환불된 purchases 이벤트만 받는 스토리지와 모든 이벤트를 받는 스토리지
+	refunds, all := &recordingSink{}, &recordingSink{}
+	route, err := pipelines.NewRoute("value.refund", nil, []string{"purchases"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noop := processors.ProcessorFunc(func(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+		return p, nil
+	})
+	p := pipelines.New(pipelines.FIFO(noop))
+	p.SetRoutes([]*pipelines.Route{route})
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, 1)
+	done := make(chan error)
+	go func() {
+		done <- p.Process(&wg, ctx, source, []storage_providers.StorageProvider{refunds, all}, errCh)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		mu.Lock()
+		settled := acked + nacked
+		mu.Unlock()
+		if settled == 6 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d payloads settled before timeout", settled)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancelFunc()
+	if err := <-done; err != nil {
+		t.Fatalf("expected the pipeline to keep running, got %v", err)
+	}
+
+	if acked != 6 || nacked != 0 {
+		t.Errorf("expected 6 payloads acked, got %d acked and %d nacked", acked, nacked)
+	}
+	// 0, 2, 4 중 환불은 0
+	if n := len(refunds.written); n != 1 {
+		t.Errorf("expected 1 refund written, got %d", n)
+	}
+	if n := len(all.written); n != 6 {
+		t.Errorf("expected 6 payloads written, got %d", n)
+	}
+}
+
+func TestPipeline_DAG(t *testing.T) {
+	// source ─┬─ enrich ─┬──────────── enriched
+	//         │          └─ join ────── joined
+	//         └─ archive ┘└─────────── raw
+	tag := func(name string) processors.ProcessorFunc {
+		return func(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+			e := p.(*payloads.Event)
+			e.Value[name] = true
+			return e, nil
+		}
+	}
+	dag, err := pipelines.NewDAG([]pipelines.Stage{
+		{Name: "enrich", Runner: pipelines.FIFO(tag("enriched"))},
+		{Name: "archive", Runner: pipelines.FIFO(tag("archived")), Inputs: []string{pipelines.SOURCE}},
+		{Name: "join", Runner: pipelines.FIFO(tag("joined")), Inputs: []string{"enrich", "archive"}},
+	}, [][]string{{"enrich"}, {"join"}, {"archive"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var acked, nacked int32
+	source := &sliceSource{}
+	for i := 0; i < 5; i++ {
+		source.items = append(source.items, &payloads.Event{
+			Value: map[string]interface{}{},
+			Ack: payloads.NewAck(
+				func() { mu.Lock(); acked++; mu.Unlock() },
+				func(error) { mu.Lock(); nacked++; mu.Unlock() },
+			),
+		})
+	}
+	enriched, joined, raw := &recordingSink{}, &recordingSink{}, &recordingSink{}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, 1)
+	done := make(chan error)
+	go func() {
+		done <- dag.Process(&wg, ctx, source, []storage_providers.StorageProvider{enriched, joined, raw}, errCh)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		mu.Lock()
+		settled := acked + nacked
+		mu.Unlock()
+		if settled == 5 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d payloads settled before timeout", settled)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancelFunc()
+	if err := <-done; err != nil {
+		t.Fatalf("expected the pipeline to keep running, got %v", err)
+	}
+
+	if acked != 5 || nacked != 0 {
+		t.Errorf("expected 5 payloads acked, got %d acked and %d nacked", acked, nacked)
+	}
+	check := func(name string, sink *recordingSink, n int, want ...string) {
+		if len(sink.written) != n {
+			t.Errorf("%s: expected %d payloads, got %d", name, n, len(sink.written))
+		}
+		for _, p := range sink.written {
+			value := p.(*payloads.Event).Value
+			if len(value) != len(want) {
+				t.Errorf("%s: expected fields %v, got %v", name, want, value)
+			}
+			for _, w := range want {
+				if value[w] != true {
+					t.Errorf("%s: expected fields %v, got %v", name, want, value)
+				}
+			}
+		}
+	}
+	check("enriched", enriched, 5, "enriched")
+	check("raw", raw, 5, "archived")
+	// join 은 두 브랜치에서 한 번씩 받는다.
+	if len(joined.written) != 10 {
+		t.Errorf("joined: expected 10 payloads, got %d", len(joined.written))
+	}
+}
+
+func TestPipeline_Batch(t *testing.T) {
+	// i 번째 이벤트를 i 개로 나눈다. 0 번째는 빈 배치가 된다.
+	split := processors.ProcessorFunc(func(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+		e := p.(*payloads.Event)
+		batch := payloads.Batch{}
+		for i := 0; i < int(e.Value["n"].(float64)); i++ {
+			batch = append(batch, e.Clone())
+		}
+		return batch, nil
+	})
+
+	var mu sync.Mutex
+	var acked, nacked int32
+	source := &sliceSource{}
+	for i := 0; i < 5; i++ {
+		source.items = append(source.items, &payloads.Event{
+			Value: map[string]interface{}{"n": float64(i)},
+			Ack: payloads.NewAck(
+				func() { mu.Lock(); acked++; mu.Unlock() },
+				func(error) { mu.Lock(); nacked++; mu.Unlock() },
+			),
+		})
+	}
+	storage := &recordingSink{}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, 1)
+	done := make(chan error)
+	p := pipelines.New(pipelines.FIFO(split))
+	go func() {
+		done <- p.Process(&wg, ctx, source, []storage_providers.StorageProvider{storage}, errCh)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		mu.Lock()
+		settled := acked + nacked
+		mu.Unlock()
+		if settled == 5 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d payloads settled before timeout", settled)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancelFunc()
+	if err := <-done; err != nil {
+		t.Fatalf("expected the pipeline to keep running, got %v", err)
+	}
+
+	if acked != 5 || nacked != 0 {
+		t.Errorf("expected 5 payloads acked, got %d acked and %d nacked", acked, nacked)
+	}
+	if n := len(storage.written); n != 10 {
+		t.Errorf("expected 10 payloads written, got %d", n)
+	}
+}
+
+func TestPipeline_ClosesSinks(t *testing.T) {
+	var mu sync.Mutex
+	var acked int32
+	source := &sliceSource{}
+	for i := 0; i < 3; i++ {
+		source.items = append(source.items, &payloads.Event{
+			Ack: payloads.NewAck(func() { mu.Lock(); acked++; mu.Unlock() }, nil),
+		})
+	}
+	storage := &bufferingSink{}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, 1)
+	done := make(chan error)
+	p := pipelines.New(pipelines.FIFO(processors.ProcessorFunc(func(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+		return p, nil
+	})))
+	go func() {
+		done <- p.Process(&wg, ctx, source, []storage_providers.StorageProvider{storage}, errCh)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for storage.len() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d payloads buffered before timeout", storage.len())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	if acked != 0 {
+		t.Errorf("expected no payload acked before the sink is closed, got %d", acked)
+	}
+	mu.Unlock()
+
+	cancelFunc()
+	if err := <-done; err != nil {
+		t.Fatalf("expected the pipeline to stop without error, got %v", err)
+	}
+	if acked != 3 {
+		t.Errorf("expected 3 payloads acked once the sink is closed, got %d", acked)
+	}
+}
+
+func TestNewDAG_Invalid(t *testing.T) {
+	noop := pipelines.FIFO(processors.ProcessorFunc(func(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+		return p, nil
+	}))
+	testCases := []struct {
+		desc          string
+		stages        []pipelines.Stage
+		storageInputs [][]string
+	}{
+		{desc: "missing name", stages: []pipelines.Stage{{Runner: noop}}},
+		{desc: "duplicate name", stages: []pipelines.Stage{{Name: "a", Runner: noop}, {Name: "a", Runner: noop}}},
+		{desc: "reserved name", stages: []pipelines.Stage{{Name: pipelines.SOURCE, Runner: noop}}},
+		{desc: "unknown input", stages: []pipelines.Stage{{Name: "a", Runner: noop, Inputs: []string{"b"}}}},
+		{desc: "unknown storage input", stages: []pipelines.Stage{{Name: "a", Runner: noop}}, storageInputs: [][]string{{"b"}}},
+		{desc: "cycle", stages: []pipelines.Stage{
+			{Name: "a", Runner: noop, Inputs: []string{"c"}},
+			{Name: "b", Runner: noop, Inputs: []string{"a"}},
+			{Name: "c", Runner: noop, Inputs: []string{"b"}},
+		}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := pipelines.NewDAG(tC.stages, tC.storageInputs); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+// 슬라이스의 페이로드를 차례로 내보내는 Source 구현체
+type sliceSource struct {
+	items []payloads.Payload
+	cur   payloads.Payload
+}
+
+func (s *sliceSource) Next(ctx context.Context) bool {
+	if len(s.items) == 0 {
+		time.Sleep(time.Millisecond)
+		return false
+	}
+	s.cur, s.items = s.items[0], s.items[1:]
+	return true
+}
+func (s *sliceSource) Payload() payloads.Payload { return s.cur }
+func (s *sliceSource) Error() error              { return nil }
+
+// 쓰여진 페이로드를 기록하는 StorageProvider, Sink 구현체
+type recordingSink struct {
+	mu      sync.Mutex
+	written []payloads.Payload
+	fail    func(p payloads.Payload) bool
+}
+
+func (s *recordingSink) Write(payload interface{}) (int, error) {
+	p := payload.(payloads.Payload)
+	if s.fail != nil && s.fail(p) {
+		err := errors.New("write failed")
+		payloads.Reject(p, err)
+		return 0, err
+	}
+	s.mu.Lock()
+	s.written = append(s.written, p)
+	s.mu.Unlock()
+	payloads.Acknowledge(p)
+	return 1, nil
+}
+
+func (s *recordingSink) Drain(ctx context.Context, p payloads.Payload) error {
+	s.Write(p)
+	return nil
+}
+
+// 페이로드를 버퍼에 모아 두었다가 Close 할 때 Acknowledge 하는 Sink 구현체,
+// err 가 있으면 Close 할 때 버퍼의 페이로드를 모두 실패 처리한다.
+type bufferingSink struct {
+	mu  sync.Mutex
+	buf []payloads.Payload
+	err error
+}
+
+func (s *bufferingSink) Write(payload interface{}) (int, error) {
+	s.mu.Lock()
+	s.buf = append(s.buf, payload.(payloads.Payload))
+	s.mu.Unlock()
+	return 0, nil
+}
+
+func (s *bufferingSink) Drain(ctx context.Context, p payloads.Payload) error {
+	s.Write(p)
+	return nil
+}
+
+func (s *bufferingSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.buf {
+		if s.err != nil {
+			payloads.Reject(p, s.err)
+		} else {
+			payloads.Acknowledge(p)
+		}
+	}
+	s.buf = nil
+	return nil
+}
+
+func (s *bufferingSink) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buf)
+}
@@ -0,0 +1,66 @@
+package pipelines
+
+import (
+	"event-data-pipeline/pkg/expr"
+	"event-data-pipeline/pkg/payloads"
+)
+
+// Route selects the payloads a storage receives. A payload matches when it
+// matches every rule that is set: the When expression, evaluated against the
+// fields of the event (see payloads.Event.Fields), and one of the Indexes,
+// Topics and Queues. A nil Route matches every payload.
+type Route struct {
+	When    *expr.Expr
+	Indexes []string
+	Topics  []string
+	Queues  []string
+}
+
+// NewRoute compiles the when expression of a route. An empty expression
+// matches every payload.
+func NewRoute(when string, indexes, topics, queues []string) (*Route, error) {
+	r := &Route{
+		Indexes: indexes,
+		Topics:  topics,
+		Queues:  queues,
+	}
+	if when != "" {
+		e, err := expr.Compile(when)
+		if err != nil {
+			return nil, err
+		}
+		r.When = e
+	}
+	return r, nil
+}
+
+// Match reports whether the payload goes to the storage of the route.
+func (r *Route) Match(p payloads.Payload) (bool, error) {
+	if r == nil {
+		return true, nil
+	}
+	e, err := payloads.AsEvent(p)
+	if err != nil {
+		return false, err
+	}
+	if !oneOf(e.Index, r.Indexes) || !oneOf(e.Source.Topic, r.Topics) || !oneOf(e.Source.Queue, r.Queues) {
+		return false, nil
+	}
+	if r.When == nil {
+		return true, nil
+	}
+	return r.When.Match(e.Fields())
+}
+
+// oneOf reports whether s is one of the values, or true when there are none.
+func oneOf(s string, values []string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package pipelines
+
+import (
+	"encoding/json"
+	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/processors"
+	"fmt"
+	"strings"
+)
+
+const DEFAULT_STAGE_RUNNER = "fifo"
+
+type jsonObj = map[string]interface{}
+
+// StageRunnerFactory creates a StageRunner that executes the given processor.
+// It returns an error when the runner config is invalid.
+type StageRunnerFactory func(proc processors.Processor, config jsonObj) (StageRunner, error)
+
+var stageRunnerFactories = make(map[string]StageRunnerFactory)
+
+// Each StageRunner implementation must Register itself
+func Register(name string, factory StageRunnerFactory) {
+	logger.Debugf("Registering stage runner factory for %s", name)
+	if factory == nil {
+		logger.Panicf("Stage runner factory %s does not exist.", name)
+	}
+	_, registered := stageRunnerFactories[name]
+	if registered {
+		logger.Errorf("Stage runner factory %s already registered. Ignoring.", name)
+	}
+	stageRunnerFactories[name] = factory
+}
+
+// CreateStageRunner is a factory method that will create the named stage
+// runner around the processor. An empty name creates the default FIFO runner.
+func CreateStageRunner(name string, proc processors.Processor, config jsonObj) (StageRunner, error) {
+	if name == "" {
+		name = DEFAULT_STAGE_RUNNER
+	}
+
+	factory, ok := stageRunnerFactories[name]
+	if !ok {
+		// Factory has not been registered.
+		// Make a list of all available stage runner factories for logging.
+		availableRunners := make([]string, 0)
+		for k := range stageRunnerFactories {
+			availableRunners = append(availableRunners, k)
+		}
+		return nil, fmt.Errorf("invalid StageRunner type. Must be one of: %s", strings.Join(availableRunners, ", "))
+	}
+
+	// Run the factory with the configuration.
+	runner, err := factory(proc, config)
+	if err != nil {
+		return nil, fmt.Errorf("%s runner: %w", name, err)
+	}
+	return runner, nil
+}
+
+// loadRunnerConfig reads the runner config object into the given struct.
+func loadRunnerConfig(config jsonObj, v interface{}) error {
+	if config == nil {
+		return nil
+	}
+	cfgData, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("error in marshalling stage runner configuration: %w", err)
+	}
+	if err := json.Unmarshal(cfgData, v); err != nil {
+		return fmt.Errorf("error in loading stage runner configuration: %w", err)
+	}
+	return nil
+}
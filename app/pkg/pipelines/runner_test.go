@@ -0,0 +1,47 @@
+package pipelines_test
+
+import (
+	"context"
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/pipelines"
+	"event-data-pipeline/pkg/processors"
+	"testing"
+)
+
+func TestCreateStageRunner(t *testing.T) {
+	proc := processors.ProcessorFunc(func(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+		return p, nil
+	})
+	testCases := []struct {
+		desc    string
+		name    string
+		config  map[string]interface{}
+		wantErr bool
+	}{
+		{desc: "default", name: ""},
+		{desc: "fifo", name: "fifo"},
+		{desc: "fixed pool", name: "fixed_pool", config: map[string]interface{}{"workers": 4}},
+		{desc: "dynamic pool", name: "dynamic_pool", config: map[string]interface{}{"min_workers": 1, "max_workers": 4}},
+		{desc: "keyed pool", name: "keyed_pool", config: map[string]interface{}{"workers": 2, "key": "value.user.id"}},
+		{desc: "unknown type", name: "round_robin", wantErr: true},
+		{desc: "malformed config", name: "fixed_pool", config: map[string]interface{}{"workers": "many"}, wantErr: true},
+		{desc: "malformed keyed config", name: "keyed_pool", config: map[string]interface{}{"key": 1}, wantErr: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			runner, err := pipelines.CreateStageRunner(tC.name, proc, tC.config)
+			if tC.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got runner %v", runner)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if runner == nil {
+				t.Errorf("expected a runner")
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package pipelines
 import (
 	"context"
 	"event-data-pipeline/pkg/payloads"
+	"io"
 )
 
 // Sink is implemented by types that can operate as the tail of a pipeline.
@@ -11,3 +12,11 @@ type Sink interface {
 	// Drain Pipeline instance.
 	Drain(context.Context, payloads.Payload) error
 }
+
+// ClosableSink is implemented by sinks buffering payloads. Close is called
+// once the sink worker stops, when the source is exhausted or the pipeline
+// context is cancelled, and writes whatever the sink still buffers.
+type ClosableSink interface {
+	Sink
+	io.Closer
+}
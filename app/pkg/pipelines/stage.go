@@ -24,3 +24,16 @@ type StageParams interface { // pipelines 패키지 아래 있는 인터페이
 	// a stage while processing payloads.
 	Error() chan<- error
 }
+
+// ErrorHandler is implemented by StageParams that apply the error policy of
+// the stage. Stage runners without it stop the stage on the first error.
+type ErrorHandler interface {
+	// Retry waits for the backoff after the attempt-th failure of a payload
+	// and reports whether the payload should be processed again.
+	Retry(ctx context.Context, attempt int) bool
+
+	// HandleError settles a payload that failed for good, e.g. by skipping
+	// it or sending it to the dead letter sink. It returns false when the
+	// stage has to stop.
+	HandleError(p payloads.Payload, err error) bool
+}
@@ -0,0 +1,146 @@
+package pipelines
+
+import (
+	"fmt"
+)
+
+// SOURCE is the input name of the stages reading from the source of the
+// pipeline.
+const SOURCE = "source"
+
+// 소스를 나타내는 노드 인덱스
+const sourceNode = -1
+
+// Stage is a named stage of a pipeline and the stages it reads from.
+type Stage struct {
+	Name   string
+	Runner StageRunner
+	// 입력 스테이지 이름 또는 SOURCE, 없으면 바로 앞 스테이지 (첫 스테이지는 소스)
+	Inputs []string
+}
+
+// NewDAG returns a pipeline whose stages form a directed acyclic graph.
+//
+// A stage reads the outputs of all its inputs (fan-in) and its output goes to
+// every stage and storage reading from it (fan-out). Each branch gets its own
+// copy of a payload and the payload is acknowledged once every branch is done
+// with it. A payload reaching a fan-in stage through several branches is
+// processed once per branch.
+//
+// Stages without inputs read from the previous stage, and storages without
+// inputs from the last stage, so a DAG without any inputs is the same linear
+// pipeline New builds. storageInputs lists the inputs of each storage in the
+// order they are passed to Process.
+func NewDAG(stages []Stage, storageInputs [][]string) (*Pipeline, error) {
+	p := &Pipeline{
+		stages:      make([]StageRunner, len(stages)),
+		stageInputs: make([][]int, len(stages)),
+	}
+
+	names := make(map[string]int, len(stages)+1)
+	names[SOURCE] = sourceNode
+	for i, s := range stages {
+		if s.Name == "" {
+			return nil, fmt.Errorf("stage %d: name is required", i)
+		}
+		if _, ok := names[s.Name]; ok {
+			return nil, fmt.Errorf("stage %d: duplicate stage name %q", i, s.Name)
+		}
+		names[s.Name] = i
+		p.stages[i] = s.Runner
+	}
+
+	resolve := func(inputs []string, defaultInput int) ([]int, error) {
+		if len(inputs) == 0 {
+			return []int{defaultInput}, nil
+		}
+		resolved := make([]int, 0, len(inputs))
+		seen := make(map[int]bool, len(inputs))
+		for _, name := range inputs {
+			idx, ok := names[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown input %q", name)
+			}
+			if !seen[idx] {
+				seen[idx] = true
+				resolved = append(resolved, idx)
+			}
+		}
+		return resolved, nil
+	}
+
+	for i, s := range stages {
+		inputs, err := resolve(s.Inputs, i-1)
+		if err != nil {
+			return nil, fmt.Errorf("stage %s: %w", s.Name, err)
+		}
+		p.stageInputs[i] = inputs
+	}
+	if err := p.checkCycles(stages); err != nil {
+		return nil, err
+	}
+
+	p.storageInputs = make([][]int, len(storageInputs))
+	for i, inputs := range storageInputs {
+		resolved, err := resolve(inputs, len(stages)-1)
+		if err != nil {
+			return nil, fmt.Errorf("storage %d: %w", i, err)
+		}
+		p.storageInputs[i] = resolved
+	}
+	return p, nil
+}
+
+// checkCycles returns an error if a stage reads, directly or not, from itself.
+func (p *Pipeline) checkCycles(stages []Stage) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(stages))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visiting:
+			return fmt.Errorf("stage %s: inputs form a cycle", stages[i].Name)
+		case visited:
+			return nil
+		}
+		state[i] = visiting
+		for _, input := range p.stageInputs[i] {
+			if input == sourceNode {
+				continue
+			}
+			if err := visit(input); err != nil {
+				return err
+			}
+		}
+		state[i] = visited
+		return nil
+	}
+	for i := range stages {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inputsOf returns the inputs of the idx-th stage, the previous stage when
+// they were not set.
+func (p *Pipeline) inputsOf(idx int) []int {
+	if idx < len(p.stageInputs) {
+		return p.stageInputs[idx]
+	}
+	return []int{idx - 1}
+}
+
+// storageInputsOf returns the inputs of the idx-th storage, the last stage
+// when they were not set.
+func (p *Pipeline) storageInputsOf(idx int) []int {
+	if idx < len(p.storageInputs) {
+		return p.storageInputs[idx]
+	}
+	return []int{len(p.stages) - 1}
+}
@@ -1,6 +1,9 @@
 package pipelines
 
-import "event-data-pipeline/pkg/payloads"
+import (
+	"context"
+	"event-data-pipeline/pkg/payloads"
+)
 
 type workerParams struct {
 	stage int
@@ -9,9 +12,22 @@ type workerParams struct {
 	inCh  <-chan payloads.Payload
 	outCh []chan<- payloads.Payload
 	errCh chan<- error
+
+	// 스테이지의 에러 처리 정책
+	handler *errorHandler
 }
 
 func (p *workerParams) StageIndex() int                   { return p.stage }
 func (p *workerParams) Input() <-chan payloads.Payload    { return p.inCh }
 func (p *workerParams) Output() []chan<- payloads.Payload { return p.outCh }
 func (p *workerParams) Error() chan<- error               { return p.errCh }
+
+// Retry implements ErrorHandler
+func (p *workerParams) Retry(ctx context.Context, attempt int) bool {
+	return p.handler.retry(ctx, attempt)
+}
+
+// HandleError implements ErrorHandler
+func (p *workerParams) HandleError(payload payloads.Payload, err error) bool {
+	return p.handler.handle(payload, err)
+}
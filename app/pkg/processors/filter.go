@@ -0,0 +1,73 @@
+package processors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"event-data-pipeline/pkg/expr"
+	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/payloads"
+)
+
+var _ Processor = new(Filter)
+
+func init() {
+	Register("filter", NewFilterProcessor)
+}
+
+// FilterCfg holds the expression events must match to go on, evaluated
+// against the fields of the event (see payloads.Event.Fields).
+//
+//	expression: value.amount > 100 && key != ""
+type FilterCfg struct {
+	Expression string `json:"expression,omitempty"`
+}
+
+// Filter drops the events that do not match its expression.
+type Filter struct {
+	expr *expr.Expr
+}
+
+func NewFilterProcessor(config jsonObj) Processor {
+	var cfg FilterCfg
+	// 바이트로 변환
+	cfgByte, _ := json.Marshal(config)
+
+	// 설정파일 Struct 으로 Load
+	json.Unmarshal(cfgByte, &cfg)
+
+	f, err := NewFilter(cfg)
+	if err != nil {
+		logger.Fatalf("error in creating filter: %v", err)
+	}
+	return f
+}
+
+// NewFilter compiles the expression of the configuration.
+func NewFilter(cfg FilterCfg) (*Filter, error) {
+	if cfg.Expression == "" {
+		return nil, errors.New("expression is required")
+	}
+	e, err := expr.Compile(cfg.Expression)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{expr: e}, nil
+}
+
+// Process implements Processor. Events that do not match return nil, so the
+// stage acknowledges and drops them.
+func (f *Filter) Process(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+	e, err := payloads.AsEvent(p)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := f.expr.Match(e.Fields())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	return e, nil
+}
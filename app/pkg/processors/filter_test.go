@@ -0,0 +1,71 @@
+package processors_test
+
+import (
+	"context"
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/pipelines"
+	"event-data-pipeline/pkg/processors"
+	"testing"
+	"time"
+)
+
+func TestFilter_Process(t *testing.T) {
+	f, err := processors.NewFilter(processors.FilterCfg{Expression: `value.amount > 100 && key != ""`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		desc  string
+		event *payloads.Event
+		match bool
+	}{
+		{desc: "matching", event: &payloads.Event{Key: "k", Value: jsonObj{"amount": float64(150)}}, match: true},
+		{desc: "small amount", event: &payloads.Event{Key: "k", Value: jsonObj{"amount": float64(50)}}},
+		{desc: "empty key", event: &payloads.Event{Value: jsonObj{"amount": float64(150)}}},
+		{desc: "missing amount", event: &payloads.Event{Key: "k"}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			p, err := f.Process(context.Background(), tC.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (p != nil) != tC.match {
+				t.Errorf("expected match=%v, got %v", tC.match, p)
+			}
+		})
+	}
+
+	if _, err := processors.NewFilter(processors.FilterCfg{Expression: "value.amount >"}); err == nil {
+		t.Errorf("expected an error for an invalid expression")
+	}
+}
+
+func TestFilter_Stage(t *testing.T) {
+	// 조건에 맞지 않아 버려진 페이로드도 소스에 Acknowledge 되어야 한다.
+	f, _ := processors.NewFilter(processors.FilterCfg{Expression: `value.amount > 100`})
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	inCh := make(chan payloads.Payload)
+	outCh := make(chan payloads.Payload, 1)
+	go pipelines.FIFO(f).Run(ctx, &workerParams{
+		stage: 0,
+		inCh:  inCh,
+		outCh: []chan<- payloads.Payload{outCh},
+		errCh: make(chan error, 1),
+	})
+
+	acked := make(chan struct{}, 1)
+	inCh <- &payloads.Event{
+		Value: jsonObj{"amount": float64(1)},
+		Ack:   payloads.NewAck(func() { acked <- struct{}{} }, nil),
+	}
+	select {
+	case <-acked:
+	case p := <-outCh:
+		t.Fatalf("expected the payload to be dropped, got %v", p)
+	case <-time.After(time.Second):
+		t.Fatalf("expected the dropped payload to be acknowledged")
+	}
+}
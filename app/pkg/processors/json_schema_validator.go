@@ -0,0 +1,185 @@
+package processors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/payloads"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+var _ Processor = new(JSONSchemaValidator)
+
+func init() {
+	Register("json_schema_validator", NewJSONSchemaValidatorProcessor)
+}
+
+// 스키마에 맞지 않는 페이로드의 처리 방법
+const (
+	// 에러를 반환해 스테이지의 on_error 정책을 따른다.
+	ON_INVALID_REJECT = "reject"
+	// 검증 에러를 메타데이터에 남기고 그대로 다음 스테이지로 넘긴다.
+	ON_INVALID_TAG = "tag"
+
+	// 검증에 실패한 페이로드의 메타데이터 키
+	SCHEMA_METADATA_KEY        = "schema"
+	SCHEMA_ERRORS_METADATA_KEY = "schema_errors"
+)
+
+// ErrSchemaValidation is returned for payloads that do not match their schema.
+var ErrSchemaValidation = errors.New("schema validation failed")
+
+// JSONSchemaValidatorCfg names the JSON Schema file of each source. The
+// schema of an event is looked up by its topic or queue name, and the default
+// schema is used for the other sources. Events without any schema pass as is.
+//
+//	schemas:
+//	  purchases: schemas/purchases.json
+//	default: schemas/event.json
+//	on_invalid: reject
+type JSONSchemaValidatorCfg struct {
+	Schemas   map[string]string `json:"schemas,omitempty"`
+	Default   string            `json:"default,omitempty"`
+	OnInvalid string            `json:"on_invalid,omitempty"`
+}
+
+// JSONSchemaValidator validates the Value of events against the JSON Schema
+// of their source.
+type JSONSchemaValidator struct {
+	schemas       map[string]*jsonschema.Schema
+	defaultSchema *jsonschema.Schema
+	onInvalid     string
+}
+
+func NewJSONSchemaValidatorProcessor(config jsonObj) Processor {
+	var cfg JSONSchemaValidatorCfg
+	// 바이트로 변환
+	cfgByte, _ := json.Marshal(config)
+
+	// 설정파일 Struct 으로 Load
+	json.Unmarshal(cfgByte, &cfg)
+
+	v, err := NewJSONSchemaValidator(cfg)
+	if err != nil {
+		logger.Fatalf("error in creating json schema validator: %v", err)
+	}
+	return v
+}
+
+// NewJSONSchemaValidator loads and compiles the schema files of the
+// configuration.
+func NewJSONSchemaValidator(cfg JSONSchemaValidatorCfg) (*JSONSchemaValidator, error) {
+	onInvalid := ON_INVALID_REJECT
+	switch cfg.OnInvalid {
+	case "", ON_INVALID_REJECT:
+	case ON_INVALID_TAG:
+		onInvalid = ON_INVALID_TAG
+	default:
+		return nil, fmt.Errorf("invalid on_invalid %q. Must be one of: %s, %s", cfg.OnInvalid, ON_INVALID_REJECT, ON_INVALID_TAG)
+	}
+	if len(cfg.Schemas) == 0 && cfg.Default == "" {
+		return nil, errors.New("at least one schema is required")
+	}
+
+	v := &JSONSchemaValidator{
+		schemas:   make(map[string]*jsonschema.Schema, len(cfg.Schemas)),
+		onInvalid: onInvalid,
+	}
+	for source, file := range cfg.Schemas {
+		schema, err := jsonschema.Compile(file)
+		if err != nil {
+			return nil, fmt.Errorf("schema of %s: %w", source, err)
+		}
+		v.schemas[source] = schema
+	}
+	if cfg.Default != "" {
+		schema, err := jsonschema.Compile(cfg.Default)
+		if err != nil {
+			return nil, fmt.Errorf("default schema: %w", err)
+		}
+		v.defaultSchema = schema
+	}
+	return v, nil
+}
+
+// Process implements Processor
+func (v *JSONSchemaValidator) Process(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+	e, err := payloads.AsEvent(p)
+	if err != nil {
+		return nil, err
+	}
+	name, schema := v.schemaOf(e)
+	if schema == nil {
+		return e, nil
+	}
+
+	err = validate(schema, e.Value)
+	if err == nil {
+		SchemaValidationTotal.WithLabelValues(name, SCHEMA_RESULT_PASS).Inc()
+		return e, nil
+	}
+	SchemaValidationTotal.WithLabelValues(name, SCHEMA_RESULT_FAIL).Inc()
+
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return nil, err
+	}
+	if v.onInvalid == ON_INVALID_REJECT {
+		return nil, fmt.Errorf("%w: %s: %v", ErrSchemaValidation, name, ve)
+	}
+
+	// 다음 스테이지에서 확인할 수 있도록 검증 에러를 남긴다.
+	if e.Metadata == nil {
+		e.Metadata = make(jsonObj)
+	}
+	e.Metadata[SCHEMA_METADATA_KEY] = name
+	e.Metadata[SCHEMA_ERRORS_METADATA_KEY] = validationErrors(ve)
+	return e, nil
+}
+
+// schemaOf returns the schema of the topic or the queue of the event.
+func (v *JSONSchemaValidator) schemaOf(e *payloads.Event) (string, *jsonschema.Schema) {
+	for _, source := range []string{e.Source.Topic, e.Source.Queue} {
+		if schema, ok := v.schemas[source]; ok && source != "" {
+			return source, schema
+		}
+	}
+	return "default", v.defaultSchema
+}
+
+// validate validates the value. Values holding types json does not have, such
+// as the time.Time of the meta injectors, are validated as their json form.
+func validate(schema *jsonschema.Schema, value jsonObj) error {
+	var doc interface{} = value
+	if value == nil {
+		doc = nil
+	}
+	err := schema.Validate(doc)
+	var typeErr jsonschema.InvalidJSONTypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+	return schema.Validate(doc)
+}
+
+// validationErrors lists the causes the validation error is made of.
+func validationErrors(ve *jsonschema.ValidationError) []string {
+	if len(ve.Causes) == 0 {
+		return []string{fmt.Sprintf("%s: %s", ve.InstanceLocation, ve.Message)}
+	}
+	var errs []string
+	for _, cause := range ve.Causes {
+		errs = append(errs, validationErrors(cause)...)
+	}
+	return errs
+}
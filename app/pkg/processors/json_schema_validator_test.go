@@ -0,0 +1,102 @@
+package processors_test
+
+import (
+	"context"
+	"errors"
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/processors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const purchaseSchema = `{
+	"type": "object",
+	"required": ["id", "price"],
+	"properties": {
+		"id": {"type": "string"},
+		"price": {"type": "number", "minimum": 0}
+	}
+}`
+
+func TestJSONSchemaValidator_Process(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "purchases.json")
+	if err := os.WriteFile(file, []byte(purchaseSchema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	purchase := func(value jsonObj) *payloads.Event {
+		return &payloads.Event{Source: payloads.EventSource{Topic: "purchases"}, Value: value}
+	}
+	testCases := []struct {
+		desc      string
+		onInvalid string
+		payload   *payloads.Event
+		wantErr   bool
+		tagged    bool
+	}{
+		{
+			desc:    "valid payload",
+			payload: purchase(jsonObj{"id": "p-1", "price": float64(10)}),
+		},
+		{
+			desc:    "valid payload with non json types",
+			payload: purchase(jsonObj{"id": "p-1", "price": float64(10), "meta": jsonObj{"at": time.Now()}}),
+		},
+		{
+			desc:    "invalid payload rejected",
+			payload: purchase(jsonObj{"id": float64(1), "price": float64(-1)}),
+			wantErr: true,
+		},
+		{
+			desc:      "invalid payload tagged",
+			onInvalid: processors.ON_INVALID_TAG,
+			payload:   purchase(jsonObj{"price": "free"}),
+			tagged:    true,
+		},
+		{
+			desc:    "payload without schema",
+			payload: &payloads.Event{Source: payloads.EventSource{Queue: "users"}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			v, err := processors.NewJSONSchemaValidator(processors.JSONSchemaValidatorCfg{
+				Schemas:   map[string]string{"purchases": file},
+				OnInvalid: tC.onInvalid,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := v.Process(context.Background(), tC.payload)
+			if tC.wantErr {
+				if !errors.Is(err, processors.ErrSchemaValidation) {
+					t.Fatalf("expected a schema validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs, _ := p.(*payloads.Event).Metadata[processors.SCHEMA_ERRORS_METADATA_KEY].([]string)
+			if tC.tagged != (len(errs) > 0) {
+				t.Errorf("expected tagged=%v, got schema errors %v", tC.tagged, errs)
+			}
+			if tC.tagged && len(errs) != 2 {
+				t.Errorf("expected 2 schema errors, got %v", errs)
+			}
+		})
+	}
+}
+
+func TestNewJSONSchemaValidator_InvalidConfig(t *testing.T) {
+	for _, cfg := range []processors.JSONSchemaValidatorCfg{
+		{},
+		{Default: "does-not-exist.json"},
+		{Default: "does-not-exist.json", OnInvalid: "drop"},
+	} {
+		if _, err := processors.NewJSONSchemaValidator(cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
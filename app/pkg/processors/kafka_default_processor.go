@@ -49,8 +49,11 @@ func (k *KafkaDefaultProcessor) Validate(ctx context.Context, p payloads.Payload
 	if err != nil {
 		return err
 	}
-	kp := p.(*payloads.KafkaPayload)
-	if kp.Value == nil {
+	e, err := payloads.AsEvent(p)
+	if err != nil {
+		return err
+	}
+	if e.Value == nil {
 		return errors.New("value is nil")
 	}
 	return nil
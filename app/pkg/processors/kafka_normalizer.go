@@ -25,21 +25,24 @@ func NewKafkaNormalizer(config jsonObj) Processor {
 
 func NormalizeKafkaPayload(ctx context.Context, p payloads.Payload) (payloads.Payload, error) { // 카푸카 메시지가 전달 되었을  특정 양식으 인덱스와 docID, 그 안에 들어갈 데이를 정규화한다.
 	// 정규화된 데이터를 파일 시스템이라고 하는 스토리 프로바이로 넘겨준다.
-	kfkPayload := p.(*payloads.KafkaPayload)
+	e, err := payloads.AsEvent(p)
+	if err != nil {
+		return nil, err
+	}
 
 	// 인덱스 생성
-	index := fmt.Sprintf("%s-%s", "event-data", kfkPayload.Timestamp.Format("01-02-2006"))
-	kfkPayload.Index = index
+	index := fmt.Sprintf("%s-%s", "event-data", e.Timestamp.Format("01-02-2006"))
+	e.Index = index
 
 	// 식별자 생성
-	docID := fmt.Sprintf("%s.%s.%v.%s", kfkPayload.Key, kfkPayload.Topic, strconv.FormatFloat(kfkPayload.Partition, 'f', 0, 64), strconv.FormatFloat(kfkPayload.Offset, 'f', 0, 64))
-	kfkPayload.DocID = docID
+	docID := fmt.Sprintf("%s.%s.%v.%s", e.Key, e.Source.Topic, strconv.FormatInt(int64(e.Source.Partition), 10), strconv.FormatInt(e.Source.Offset, 10))
+	e.DocID = docID
 
 	// 데이터 생성
-	data, err := json.Marshal(kfkPayload.Value)
+	data, err := json.Marshal(e.Value)
 	if err != nil {
 		return nil, err
 	}
-	kfkPayload.Data = data
-	return kfkPayload, nil
+	e.Data = data
+	return e, nil
 }
@@ -8,6 +8,12 @@ import (
 const (
 	EDP_KAFKA_PROCESSOR_PROCESS_TOTAL      = "edp_kafka_processor_process_total"
 	EDP_KAFKA_PROCESSOR_PROCESS_TOTAL_HELP = "the number of messages that kafka default processor processed in total"
+
+	EDP_SCHEMA_VALIDATION_TOTAL      = "edp_schema_validation_total"
+	EDP_SCHEMA_VALIDATION_TOTAL_HELP = "the number of payloads validated against each json schema, by result"
+
+	SCHEMA_RESULT_PASS = "pass"
+	SCHEMA_RESULT_FAIL = "fail"
 )
 
 var (
@@ -15,8 +21,15 @@ var (
 		Name: EDP_KAFKA_PROCESSOR_PROCESS_TOTAL,
 		Help: EDP_KAFKA_PROCESSOR_PROCESS_TOTAL_HELP},
 	)
+
+	SchemaValidationTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: EDP_SCHEMA_VALIDATION_TOTAL,
+		Help: EDP_SCHEMA_VALIDATION_TOTAL_HELP},
+		[]string{"schema", "result"},
+	)
 )
 
 func init() {
 	prometheus.Register(KafkaProcessTotal)
+	prometheus.Register(SchemaValidationTotal)
 }
@@ -44,8 +44,11 @@ func (r *RabbitMQDefaultProcessor) Validate(ctx context.Context, p payloads.Payl
 	if err != nil {
 		return err
 	}
-	rp := p.(*payloads.RabbitMQPayload)
-	if rp.Value == nil {
+	e, err := payloads.AsEvent(p)
+	if err != nil {
+		return err
+	}
+	if e.Value == nil {
 		return errors.New("value is nil")
 	}
 	return nil
@@ -21,7 +21,10 @@ func NewRabbitMQMetaInjector(config jsonObj) Processor {
 // Process implements Processor
 func (*RabbitMQMetaInjector) Process(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
 	logger.Debugf("InjectMetaRabbitMQPayload processing...")
-	rbbtPayload := p.(*payloads.RabbitMQPayload)
+	e, err := payloads.AsEvent(p)
+	if err != nil {
+		return nil, err
+	}
 
 	meta := make(jsonObj)
 	meta["data-processor-id"] = "rabbitmq-event-data-processor"
@@ -29,8 +32,11 @@ func (*RabbitMQMetaInjector) Process(ctx context.Context, p payloads.Payload) (p
 	meta["data-processor-env"] = "local"
 
 	// 이 안에서 데이터를 처리한다.
-	rbbtPayload.Value["meta"] = meta
+	if e.Value == nil {
+		e.Value = make(jsonObj)
+	}
+	e.Value["meta"] = meta
 
-	return rbbtPayload, nil
+	return e, nil
 
 }
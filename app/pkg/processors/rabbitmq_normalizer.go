@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"event-data-pipeline/pkg/payloads"
 	"fmt"
+	"strconv"
+	"time"
 )
 
 var _ Processor = new(ProcessorFunc)
@@ -20,21 +22,51 @@ func NewNormalizeRabbitMQPayloadProcessor(config jsonObj) Processor {
 }
 
 func NormalizeRabbitMQPayload(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
-	rbbtMQPayload := p.(*payloads.RabbitMQPayload)
+	e, err := payloads.AsEvent(p)
+	if err != nil {
+		return nil, err
+	}
 
 	// 인덱스 생성
-	index := fmt.Sprintf("%s-%s", "event-data", rbbtMQPayload.Timestamp.Format("01-02-2006"))
-	rbbtMQPayload.Index = index
+	index := fmt.Sprintf("%s-%s", "event-data", e.Timestamp.Format("01-02-2006"))
+	e.Index = index
 
 	// 식별자 생성
-	docID := fmt.Sprintf("%s.%v.%s.%s", rbbtMQPayload.Queue, rbbtMQPayload.Id, rbbtMQPayload.Email, rbbtMQPayload.Gender)
-	rbbtMQPayload.DocID = docID
+	docID := fmt.Sprintf("%s.%s.%s.%s", e.Source.Queue, fieldString(e.Value, "id"), fieldString(e.Value, "email"), fieldString(e.Value, "gender"))
+	e.DocID = docID
 
 	// 데이터 생성
-	data, err := json.Marshal(rbbtMQPayload.Value)
+	data, err := json.Marshal(e.Value)
 	if err != nil {
 		return nil, err
 	}
-	rbbtMQPayload.Data = data
-	return rbbtMQPayload, nil
+	e.Data = data
+	return e, nil
+}
+
+// fieldString formats a top-level field of the value, or returns an empty
+// string when it is not set.
+func fieldString(value jsonObj, field string) string {
+	return stringify(value[field])
+}
+
+// stringify formats a json value as a string, objects and arrays as json.
+func stringify(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		return val.Format(time.RFC3339)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
 }
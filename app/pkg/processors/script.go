@@ -0,0 +1,469 @@
+package processors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/payloads"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+var _ Processor = new(Script)
+
+func init() {
+	Register("script", NewScriptProcessor)
+}
+
+const (
+	SCRIPT_DEFAULT_FUNCTION         = "process"
+	SCRIPT_DEFAULT_TIMEOUT_MS       = 100
+	SCRIPT_DEFAULT_MAX_STEPS        = 1000000
+	SCRIPT_DEFAULT_MAX_OUTPUT_BYTES = 1 << 20
+	SCRIPT_DEFAULT_MAX_EVENTS       = 100
+	SCRIPT_DEFAULT_MAX_ALLOC_BYTES  = 16 << 20
+
+	// 스크립트 결과 변환 시 허용하는 최대 중첩 깊이
+	scriptMaxDepth = 64
+)
+
+var (
+	// ErrScriptLimit is returned when a script call exceeds one of its limits.
+	ErrScriptLimit = errors.New("script limit exceeded")
+)
+
+// ScriptCfg configures a processor running a Starlark script
+// (https://github.com/bazelbuild/starlark) on each event.
+//
+// The script defines a function taking the event as a dict with the keys
+// key, value, metadata, headers, source, timestamp (RFC3339), index and
+// doc_id. The function returns:
+//
+//   - the event, modified or not, to pass it on,
+//   - None to drop it,
+//   - a list of events to split it. Each event of the list goes on its own.
+//
+// source and timestamp are read-only, changes to them are ignored.
+//
+// The script has no access to the file system, the network or load
+// statements. The json module is predeclared.
+//
+// Starlark does not account memory itself. Each call has a budget of
+// max_alloc_bytes instead, charged with the estimated size of the values
+// built by the operations that can grow a value faster than one element per
+// step: + and * on strings and lists, % formatting, join, replace, format,
+// extend, str, repr, json.encode and the list conversions. Values built one
+// element per step are bounded by max_steps.
+type ScriptCfg struct {
+	// 인라인 스크립트, file 과 함께 쓸 수 없다.
+	Source string `json:"source,omitempty"`
+	File   string `json:"file,omitempty"`
+	// 이벤트마다 호출할 함수, 기본 process
+	Function string `json:"function,omitempty"`
+
+	// 호출당 제한
+	TimeoutMs      int    `json:"timeout_ms,omitempty"`
+	MaxSteps       uint64 `json:"max_steps,omitempty"`
+	MaxOutputBytes int    `json:"max_output_bytes,omitempty"`
+	MaxEvents      int    `json:"max_events,omitempty"`
+	MaxAllocBytes  int64  `json:"max_alloc_bytes,omitempty"`
+}
+
+// Script runs a Starlark function on each event.
+type Script struct {
+	name string
+	fn   starlark.Callable
+
+	timeout        time.Duration
+	maxSteps       uint64
+	maxOutputBytes int
+	maxEvents      int
+	maxAllocBytes  int64
+}
+
+func NewScriptProcessor(config jsonObj) Processor {
+	var cfg ScriptCfg
+	// 바이트로 변환
+	cfgByte, _ := json.Marshal(config)
+
+	// 설정파일 Struct 으로 Load
+	json.Unmarshal(cfgByte, &cfg)
+
+	s, err := NewScript(cfg)
+	if err != nil {
+		logger.Fatalf("error in creating script: %v", err)
+	}
+	return s
+}
+
+// NewScript loads the script of the configuration and looks up its
+// function. Top-level statements run once, here, under the same limits as
+// the calls.
+func NewScript(cfg ScriptCfg) (*Script, error) {
+	name := "<source>"
+	var src interface{}
+	switch {
+	case cfg.Source != "" && cfg.File != "":
+		return nil, errors.New("source and file are mutually exclusive")
+	case cfg.Source != "":
+		src = cfg.Source
+	case cfg.File != "":
+		b, err := ioutil.ReadFile(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		name, src = cfg.File, b
+	default:
+		return nil, errors.New("source or file is required")
+	}
+
+	s := &Script{
+		name:           name,
+		timeout:        time.Duration(cfg.TimeoutMs) * time.Millisecond,
+		maxSteps:       cfg.MaxSteps,
+		maxOutputBytes: cfg.MaxOutputBytes,
+		maxEvents:      cfg.MaxEvents,
+		maxAllocBytes:  cfg.MaxAllocBytes,
+	}
+	if s.timeout <= 0 {
+		s.timeout = SCRIPT_DEFAULT_TIMEOUT_MS * time.Millisecond
+	}
+	if s.maxSteps == 0 {
+		s.maxSteps = SCRIPT_DEFAULT_MAX_STEPS
+	}
+	if s.maxOutputBytes <= 0 {
+		s.maxOutputBytes = SCRIPT_DEFAULT_MAX_OUTPUT_BYTES
+	}
+	if s.maxEvents <= 0 {
+		s.maxEvents = SCRIPT_DEFAULT_MAX_EVENTS
+	}
+	if s.maxAllocBytes <= 0 {
+		s.maxAllocBytes = SCRIPT_DEFAULT_MAX_ALLOC_BYTES
+	}
+
+	f, err := syntax.Parse(name, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReserved(f); err != nil {
+		return nil, err
+	}
+	// 할당을 검사하는 함수를 거치도록 스크립트를 재작성한다.
+	rewriteAllocs(f)
+	predeclared := scriptPredeclared()
+	prog, err := starlark.FileProgram(f, predeclared.Has)
+	if err != nil {
+		return nil, err
+	}
+	thread, stop := s.newThread(context.Background())
+	globals, err := prog.Init(thread, predeclared)
+	if limitErr := stop(); err != nil {
+		return nil, scriptError(err, limitErr)
+	}
+	// 전역 값을 동결해 여러 워커가 동시에 호출할 수 있게 한다.
+	globals.Freeze()
+
+	function := cfg.Function
+	if function == "" {
+		function = SCRIPT_DEFAULT_FUNCTION
+	}
+	fn, ok := globals[function].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("%s: function %s is not defined", name, function)
+	}
+	s.fn = fn
+	return s, nil
+}
+
+// newThread returns a thread cancelled after the timeout or when ctx is
+// done. stop must be called once the thread is no longer used, it returns an
+// ErrScriptLimit error when the thread ran out of time, steps or allocation
+// budget.
+func (s *Script) newThread(ctx context.Context) (*starlark.Thread, func() error) {
+	thread := &starlark.Thread{
+		Name: s.name,
+		Print: func(_ *starlark.Thread, msg string) {
+			logger.Debugf("%s: %s", s.name, msg)
+		},
+	}
+	thread.SetMaxExecutionSteps(s.maxSteps)
+	budget := &allocBudget{max: s.maxAllocBytes}
+	thread.SetLocal(scriptAllocKey, budget)
+
+	var timedOut int32
+	timer := time.AfterFunc(s.timeout, func() {
+		atomic.StoreInt32(&timedOut, 1)
+		thread.Cancel("timeout")
+	})
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel(ctx.Err().Error())
+		case <-done:
+		}
+	}()
+	return thread, func() error {
+		timer.Stop()
+		close(done)
+		switch {
+		case atomic.LoadInt32(&timedOut) == 1:
+			return fmt.Errorf("%w: timeout of %v", ErrScriptLimit, s.timeout)
+		case thread.ExecutionSteps() >= s.maxSteps:
+			return fmt.Errorf("%w: max_steps of %d", ErrScriptLimit, s.maxSteps)
+		case budget.exceeded:
+			return fmt.Errorf("%w: max_alloc_bytes of %d", ErrScriptLimit, s.maxAllocBytes)
+		}
+		return nil
+	}
+}
+
+// Process implements Processor.
+func (s *Script) Process(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+	e, err := payloads.AsEvent(p)
+	if err != nil {
+		return nil, err
+	}
+	arg, err := toStarlark(e.Fields(), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	thread, stop := s.newThread(ctx)
+	result, err := starlark.Call(thread, s.fn, starlark.Tuple{arg}, nil)
+	if limitErr := stop(); err != nil {
+		return nil, scriptError(err, limitErr)
+	}
+
+	budget := s.maxOutputBytes
+	switch result := result.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case *starlark.Dict:
+		if err := s.apply(e, result, &budget); err != nil {
+			return nil, err
+		}
+		return e, nil
+	case *starlark.List:
+		if result.Len() > s.maxEvents {
+			return nil, fmt.Errorf("%w: %d events returned, max_events is %d", ErrScriptLimit, result.Len(), s.maxEvents)
+		}
+		batch := make(payloads.Batch, 0, result.Len())
+		for i := 0; i < result.Len(); i++ {
+			d, ok := result.Index(i).(*starlark.Dict)
+			if !ok {
+				return nil, fmt.Errorf("event %d: expected a dict, got %s", i, result.Index(i).Type())
+			}
+			// 분할된 이벤트는 원본의 Ack 를 공유한다.
+			out := e.Clone().(*payloads.Event)
+			if err := s.apply(out, d, &budget); err != nil {
+				return nil, fmt.Errorf("event %d: %w", i, err)
+			}
+			batch = append(batch, out)
+		}
+		return batch, nil
+	}
+	return nil, fmt.Errorf("%s returned %s, expected a dict, a list or None", s.name, result.Type())
+}
+
+// apply sets the fields of the event from the dict returned by the script.
+// Keys missing from the dict leave the fields of the event unchanged.
+func (s *Script) apply(e *payloads.Event, d *starlark.Dict, budget *int) error {
+	obj, err := fromStarlark(d, 0, budget)
+	if err != nil {
+		return err
+	}
+	if *budget < 0 {
+		return fmt.Errorf("%w: output is larger than %d bytes", ErrScriptLimit, s.maxOutputBytes)
+	}
+	fields := obj.(map[string]interface{})
+
+	for _, name := range []string{"key", "index", "doc_id"} {
+		v, ok := fields[name]
+		if !ok {
+			continue
+		}
+		str, ok := v.(string)
+		if !ok && v != nil {
+			return fmt.Errorf("%s: expected a string, got %T", name, v)
+		}
+		switch name {
+		case "key":
+			e.Key = str
+		case "index":
+			e.Index = str
+		case "doc_id":
+			e.DocID = str
+		}
+	}
+	for _, name := range []string{"value", "metadata"} {
+		v, ok := fields[name]
+		if !ok {
+			continue
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok && v != nil {
+			return fmt.Errorf("%s: expected a dict, got %T", name, v)
+		}
+		if name == "value" {
+			e.Value = m
+		} else {
+			e.Metadata = m
+		}
+	}
+	if v, ok := fields["headers"]; ok {
+		m, ok := v.(map[string]interface{})
+		if !ok && v != nil {
+			return fmt.Errorf("headers: expected a dict, got %T", v)
+		}
+		// 헤더는 클론 간에 공유되므로 새 맵을 만든다.
+		headers := make(map[string]string, len(m))
+		for k, hv := range m {
+			headers[k] = stringify(hv)
+		}
+		e.Headers = headers
+	}
+	return nil
+}
+
+// scriptError returns limitErr when the script was cancelled for exceeding a
+// limit, or err with the backtrace of the script.
+func scriptError(err, limitErr error) error {
+	if limitErr != nil {
+		return limitErr
+	}
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return fmt.Errorf("%s", evalErr.Backtrace())
+	}
+	return err
+}
+
+// toStarlark converts a json value to a Starlark value. Times are converted
+// to RFC3339 strings.
+func toStarlark(v interface{}, depth int) (starlark.Value, error) {
+	if depth > scriptMaxDepth {
+		return nil, fmt.Errorf("%w: value is nested more than %d levels", ErrScriptLimit, scriptMaxDepth)
+	}
+	switch v := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(v), nil
+	case string:
+		return starlark.String(v), nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return starlark.MakeInt64(int64(v)), nil
+		}
+		return starlark.Float(v), nil
+	case int:
+		return starlark.MakeInt(v), nil
+	case int64:
+		return starlark.MakeInt64(v), nil
+	case time.Time:
+		if v.IsZero() {
+			return starlark.None, nil
+		}
+		return starlark.String(v.Format(time.RFC3339Nano)), nil
+	case []interface{}:
+		elems := make([]starlark.Value, len(v))
+		for i, elem := range v {
+			sv, err := toStarlark(elem, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = sv
+		}
+		return starlark.NewList(elems), nil
+	case map[string]interface{}:
+		d := starlark.NewDict(len(v))
+		for k, elem := range v {
+			sv, err := toStarlark(elem, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			d.SetKey(starlark.String(k), sv)
+		}
+		return d, nil
+	}
+	// 그 밖의 타입은 json 으로 변환
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var obj interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, err
+	}
+	return toStarlark(obj, depth)
+}
+
+// fromStarlark converts a Starlark value to a json value, subtracting its
+// approximate size from budget.
+func fromStarlark(v starlark.Value, depth int, budget *int) (interface{}, error) {
+	if depth > scriptMaxDepth {
+		return nil, fmt.Errorf("%w: output is nested more than %d levels", ErrScriptLimit, scriptMaxDepth)
+	}
+	if *budget < 0 {
+		return nil, nil
+	}
+	switch v := v.(type) {
+	case starlark.NoneType:
+		*budget -= 4
+		return nil, nil
+	case starlark.Bool:
+		*budget -= 5
+		return bool(v), nil
+	case starlark.String:
+		*budget -= len(v) + 2
+		return string(v), nil
+	case starlark.Int:
+		*budget -= 8
+		if i, ok := v.Int64(); ok {
+			return float64(i), nil
+		}
+		return float64(v.Float()), nil
+	case starlark.Float:
+		*budget -= 8
+		return float64(v), nil
+	case *starlark.List, starlark.Tuple:
+		iter := v.(starlark.Iterable).Iterate()
+		defer iter.Done()
+		arr := []interface{}{}
+		var elem starlark.Value
+		for iter.Next(&elem) {
+			jv, err := fromStarlark(elem, depth+1, budget)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, jv)
+		}
+		*budget -= 2
+		return arr, nil
+	case *starlark.Dict:
+		obj := make(map[string]interface{}, v.Len())
+		for _, item := range v.Items() {
+			k, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("dict keys must be strings, got %s", item[0].Type())
+			}
+			jv, err := fromStarlark(item[1], depth+1, budget)
+			if err != nil {
+				return nil, err
+			}
+			*budget -= len(k) + 3
+			obj[string(k)] = jv
+		}
+		*budget -= 2
+		return obj, nil
+	}
+	return nil, fmt.Errorf("unexpected %s", v.Type())
+}
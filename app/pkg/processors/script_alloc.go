@@ -0,0 +1,813 @@
+package processors
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	starlarkjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// Starlark does not account memory, and a single step can build a value
+// many times larger than its operands: "x" * n, s + s, l.extend(l),
+// list(range(n)) or str() of a list holding the same string many times.
+//
+// To bound the memory of a call, scripts are rewritten so that every
+// operation that can grow a value faster than one element per step goes
+// through a checked builtin. The builtin estimates the size of the result
+// before it is built and charges it to the allocation budget of the call,
+// which fails the call with ErrScriptLimit once max_alloc_bytes is used up.
+// Operations that build values one element per step, such as append or
+// comprehensions, are bounded by max_steps instead.
+
+const (
+	// 리스트, 튜플 원소 하나의 크기 (starlark.Value 인터페이스)
+	scriptElemSize = 16
+	// 스레드에 할당 예산을 저장하는 키
+	scriptAllocKey = "alloc"
+
+	// 재작성된 스크립트가 호출하는 검사 함수
+	scriptBinaryFn   = "__binary__"
+	scriptSetIndexFn = "__setindex__"
+	scriptSetAttrFn  = "__setattr__"
+	scriptAttrFn     = "__attr__"
+)
+
+// 결과가 피연산자보다 빠르게 커질 수 있는 이항 연산자와 복합 대입 연산자
+var scriptCheckedOps = map[syntax.Token]syntax.Token{
+	syntax.PLUS:       syntax.PLUS,
+	syntax.STAR:       syntax.STAR,
+	syntax.PERCENT:    syntax.PERCENT,
+	syntax.PLUS_EQ:    syntax.PLUS,
+	syntax.STAR_EQ:    syntax.STAR,
+	syntax.PERCENT_EQ: syntax.PERCENT,
+}
+
+// 결과가 커질 수 있는 문자열, 리스트 메서드
+var scriptCheckedMethods = map[string]bool{
+	"join":    true,
+	"replace": true,
+	"format":  true,
+	"extend":  true,
+}
+
+// allocBudget is the number of bytes a call may still allocate.
+type allocBudget struct {
+	max      int64
+	used     int64
+	exceeded bool
+}
+
+func (b *allocBudget) charge(n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	b.used = addSize(b.used, n)
+	if b.used > b.max {
+		b.exceeded = true
+		return fmt.Errorf("%w: max_alloc_bytes of %d", ErrScriptLimit, b.max)
+	}
+	return nil
+}
+
+// remaining returns how many bytes can still be charged, plus one, so
+// estimates can stop walking a value as soon as it is too large.
+func (b *allocBudget) remaining() int64 {
+	return b.max - b.used + 1
+}
+
+func budgetOf(thread *starlark.Thread) *allocBudget {
+	if b, ok := thread.Local(scriptAllocKey).(*allocBudget); ok {
+		return b
+	}
+	return &allocBudget{max: math.MaxInt64}
+}
+
+// scriptPredeclared returns the values predeclared in scripts: the json
+// module and the checked builtins, which replace the universal builtins of
+// the same name.
+func scriptPredeclared() starlark.StringDict {
+	jsonMembers := make(starlark.StringDict, len(starlarkjson.Module.Members))
+	for name, member := range starlarkjson.Module.Members {
+		jsonMembers[name] = member
+	}
+	jsonMembers["encode"] = checkedBuiltin("json.encode", starlarkjson.Module.Members["encode"], estimateRepr)
+	jsonMembers["indent"] = checkedBuiltin("json.indent", starlarkjson.Module.Members["indent"], estimateIndent)
+
+	predeclared := starlark.StringDict{
+		"json": &starlarkstruct.Module{Name: "json", Members: jsonMembers},
+
+		scriptBinaryFn:   starlark.NewBuiltin(scriptBinaryFn, checkedBinary),
+		scriptSetIndexFn: starlark.NewBuiltin(scriptSetIndexFn, checkedSetIndex),
+		scriptSetAttrFn:  starlark.NewBuiltin(scriptSetAttrFn, checkedSetAttr),
+		scriptAttrFn:     starlark.NewBuiltin(scriptAttrFn, checkedAttr),
+		"getattr":        starlark.NewBuiltin("getattr", checkedGetattr),
+	}
+	predeclared["str"] = checkedBuiltin("str", starlark.Universe["str"], estimateStr)
+	for _, name := range []string{"repr", "print"} {
+		predeclared[name] = checkedBuiltin(name, starlark.Universe[name], estimateRepr)
+	}
+	for _, name := range []string{"list", "tuple", "sorted", "reversed", "enumerate", "zip", "dict"} {
+		predeclared[name] = checkedBuiltin(name, starlark.Universe[name], estimateElems)
+	}
+	return predeclared
+}
+
+// checkedBuiltin returns a builtin that charges the estimated size of the
+// result of fn before calling it.
+func checkedBuiltin(name string, fn starlark.Value, estimate func(args starlark.Tuple, kwargs []starlark.Tuple, limit int64) int64) *starlark.Builtin {
+	return starlark.NewBuiltin(name, func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		budget := budgetOf(thread)
+		if err := budget.charge(estimate(args, kwargs, budget.remaining())); err != nil {
+			return nil, err
+		}
+		return starlark.Call(thread, fn, args, kwargs)
+	})
+}
+
+// estimateStr estimates the size of str(x). A string is returned as is, so
+// it is not copied.
+func estimateStr(args starlark.Tuple, kwargs []starlark.Tuple, limit int64) int64 {
+	if len(args) == 1 && len(kwargs) == 0 {
+		if _, ok := args[0].(starlark.String); ok {
+			return 0
+		}
+	}
+	return estimateRepr(args, kwargs, limit)
+}
+
+// estimateRepr estimates the size of the string representation of the
+// arguments.
+func estimateRepr(args starlark.Tuple, kwargs []starlark.Tuple, limit int64) int64 {
+	var size int64
+	for _, arg := range args {
+		size = addSize(size, reprSize(arg, limit-size))
+	}
+	for _, kv := range kwargs {
+		size = addSize(size, reprSize(kv[1], limit-size))
+	}
+	return size
+}
+
+// estimateIndent estimates the size of json.indent(str, prefix=, indent=):
+// every value can go on its own line, indented by its depth.
+func estimateIndent(args starlark.Tuple, kwargs []starlark.Tuple, limit int64) int64 {
+	if len(args) == 0 {
+		return 0
+	}
+	s, ok := args[0].(starlark.String)
+	if !ok {
+		return 0
+	}
+	var lines, depth, maxDepth int64
+	for _, c := range []byte(s) {
+		switch c {
+		case '[', '{':
+			lines++
+			if depth++; depth > maxDepth {
+				maxDepth = depth
+			}
+		case ']', '}':
+			lines++
+			depth--
+		case ',':
+			lines++
+		}
+	}
+	prefix := 0
+	indent := 1
+	for _, kv := range kwargs {
+		if v, ok := kv[1].(starlark.String); ok {
+			switch string(kv[0].(starlark.String)) {
+			case "prefix":
+				prefix = len(v)
+			case "indent":
+				indent = len(v)
+			}
+		}
+	}
+	perLine := addSize(int64(prefix+2), mulSize(int64(indent), maxDepth))
+	return addSize(int64(len(s)), mulSize(lines, perLine))
+}
+
+// estimateElems estimates the size of the list or tuple built from the
+// arguments, e.g. list(range(n)).
+func estimateElems(args starlark.Tuple, kwargs []starlark.Tuple, limit int64) int64 {
+	var elems int64
+	for _, arg := range args {
+		if n := starlark.Len(arg); n > 0 {
+			elems = addSize(elems, int64(n))
+		}
+	}
+	return mulSize(elems, scriptElemSize)
+}
+
+// checkedBinary implements __binary__(op, x, y): x op y, or x op= y when op
+// ends with =, after charging the size of the result.
+func checkedBinary(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var op string
+	var x, y starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 3, &op, &x, &y); err != nil {
+		return nil, err
+	}
+	return binary(thread, op, x, y)
+}
+
+func binary(thread *starlark.Thread, op string, x, y starlark.Value) (starlark.Value, error) {
+	tok, inplace, err := parseOp(op)
+	if err != nil {
+		return nil, err
+	}
+	budget := budgetOf(thread)
+
+	// 리스트의 += 는 새 리스트를 만들지 않고 원래 리스트를 확장한다.
+	if list, ok := x.(*starlark.List); ok && inplace && tok == syntax.PLUS {
+		if iterable, ok := y.(starlark.Iterable); ok {
+			if err := budget.charge(estimateElems(starlark.Tuple{y}, nil, 0)); err != nil {
+				return nil, err
+			}
+			return list, extendList(list, iterable)
+		}
+	}
+
+	if err := budget.charge(binarySize(tok, x, y, budget.remaining())); err != nil {
+		return nil, err
+	}
+	return starlark.Binary(tok, x, y)
+}
+
+// parseOp converts the operator passed by the rewritten script.
+func parseOp(op string) (syntax.Token, bool, error) {
+	inplace := strings.HasSuffix(op, "=")
+	switch strings.TrimSuffix(op, "=") {
+	case "+":
+		return syntax.PLUS, inplace, nil
+	case "*":
+		return syntax.STAR, inplace, nil
+	case "%":
+		return syntax.PERCENT, inplace, nil
+	}
+	return 0, false, fmt.Errorf("unsupported operator %q", op)
+}
+
+func extendList(list *starlark.List, iterable starlark.Iterable) error {
+	// 자기 자신으로 확장할 때 원소가 계속 늘어나지 않도록 먼저 복사한다.
+	var elems []starlark.Value
+	iter := iterable.Iterate()
+	var elem starlark.Value
+	for iter.Next(&elem) {
+		elems = append(elems, elem)
+	}
+	iter.Done()
+	for _, elem := range elems {
+		if err := list.Append(elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// binarySize estimates the size of x op y.
+func binarySize(op syntax.Token, x, y starlark.Value, limit int64) int64 {
+	switch op {
+	case syntax.PLUS:
+		return addSize(valueSize(x), valueSize(y))
+	case syntax.STAR:
+		// 반복: 시퀀스 * 정수 또는 정수 * 시퀀스
+		if n, ok := y.(starlark.Int); ok {
+			if _, ok := x.(starlark.Int); ok {
+				return (intBits(x.(starlark.Int)) + intBits(n)) / 8
+			}
+			return mulSize(valueSize(x), intCount(n))
+		}
+		if n, ok := x.(starlark.Int); ok {
+			return mulSize(valueSize(y), intCount(n))
+		}
+	case syntax.PERCENT:
+		// 문자열 포맷: 각 % 지시자가 가장 긴 인자로 바뀔 수 있다.
+		format, ok := x.(starlark.String)
+		if !ok {
+			return 0
+		}
+		var arg int64
+		if tuple, ok := y.(starlark.Tuple); ok {
+			for _, v := range tuple {
+				if size := reprSize(v, limit); size > arg {
+					arg = size
+				}
+			}
+		} else {
+			arg = reprSize(y, limit)
+		}
+		return addSize(int64(len(format)), mulSize(int64(strings.Count(string(format), "%")), arg))
+	}
+	return 0
+}
+
+// checkedSetIndex implements __setindex__(x, i, op, y): x[i] op= y.
+func checkedSetIndex(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x, key, y starlark.Value
+	var op string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 4, &x, &key, &op, &y); err != nil {
+		return nil, err
+	}
+	switch x := x.(type) {
+	case starlark.HasSetKey:
+		v, found, err := x.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("key %v not in %s", key, x.Type())
+		}
+		if v, err = binary(thread, op, v, y); err != nil {
+			return nil, err
+		}
+		return starlark.None, x.SetKey(key, v)
+	case starlark.HasSetIndex:
+		i, err := starlark.AsInt32(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s index: %v", x.Type(), err)
+		}
+		if i < 0 {
+			i += x.Len()
+		}
+		if i < 0 || i >= x.Len() {
+			return nil, fmt.Errorf("%s index %v out of range [%d:%d]", x.Type(), key, -x.Len(), x.Len()-1)
+		}
+		v, err := binary(thread, op, x.Index(i), y)
+		if err != nil {
+			return nil, err
+		}
+		return starlark.None, x.SetIndex(i, v)
+	}
+	return nil, fmt.Errorf("%s value does not support item assignment", x.Type())
+}
+
+// checkedSetAttr implements __setattr__(x, name, op, y): x.name op= y.
+func checkedSetAttr(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x, y starlark.Value
+	var name, op string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 4, &x, &name, &op, &y); err != nil {
+		return nil, err
+	}
+	setter, ok := x.(starlark.HasSetField)
+	if !ok {
+		return nil, fmt.Errorf("can't assign to .%s field of %s", name, x.Type())
+	}
+	v, err := setter.Attr(name)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, fmt.Errorf("%s has no .%s field or method", x.Type(), name)
+	}
+	if v, err = binary(thread, op, v, y); err != nil {
+		return nil, err
+	}
+	return starlark.None, setter.SetField(name, v)
+}
+
+// checkedAttr implements __attr__(x, name): x.name, with the methods that
+// can grow a value replaced by checked ones.
+func checkedAttr(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x starlark.Value
+	var name string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &x, &name); err != nil {
+		return nil, err
+	}
+	return attr(x, name, nil)
+}
+
+// checkedGetattr replaces getattr(x, name[, default]) so methods cannot be
+// looked up around the checks.
+func checkedGetattr(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x, dflt starlark.Value
+	var name string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &x, &name, &dflt); err != nil {
+		return nil, err
+	}
+	return attr(x, name, dflt)
+}
+
+func attr(x starlark.Value, name string, dflt starlark.Value) (starlark.Value, error) {
+	obj, ok := x.(starlark.HasAttrs)
+	var v starlark.Value
+	if ok {
+		var err error
+		if v, err = obj.Attr(name); err != nil {
+			return nil, err
+		}
+	}
+	if v == nil {
+		if dflt != nil {
+			return dflt, nil
+		}
+		return nil, fmt.Errorf("%s has no .%s field or method", x.Type(), name)
+	}
+	method, ok := v.(*starlark.Builtin)
+	if !ok || !scriptCheckedMethods[name] {
+		return v, nil
+	}
+	switch x := x.(type) {
+	case starlark.String:
+		return checkedBuiltin(method.Name(), method, func(args starlark.Tuple, kwargs []starlark.Tuple, limit int64) int64 {
+			return stringMethodSize(x, name, args, kwargs, limit)
+		}), nil
+	case *starlark.List:
+		return checkedBuiltin(method.Name(), method, estimateElems), nil
+	}
+	return v, nil
+}
+
+// stringMethodSize estimates the size of the result of s.join, s.replace
+// and s.format.
+func stringMethodSize(s starlark.String, name string, args starlark.Tuple, kwargs []starlark.Tuple, limit int64) int64 {
+	switch name {
+	case "join":
+		if len(args) != 1 {
+			return 0
+		}
+		iter := starlark.Iterate(args[0])
+		if iter == nil {
+			return 0
+		}
+		defer iter.Done()
+		var size, n int64
+		var elem starlark.Value
+		for size <= limit && iter.Next(&elem) {
+			if n++; n > 1 {
+				size = addSize(size, int64(len(s)))
+			}
+			size = addSize(size, valueSize(elem))
+		}
+		return size
+	case "replace":
+		if len(args) < 2 {
+			return 0
+		}
+		old, ok1 := args[0].(starlark.String)
+		new, ok2 := args[1].(starlark.String)
+		if !ok1 || !ok2 || len(new) <= len(old) {
+			return int64(len(s))
+		}
+		n := int64(strings.Count(string(s), string(old)))
+		if len(args) > 2 {
+			if count, ok := args[2].(starlark.Int); ok && count.Sign() >= 0 {
+				if c := intCount(count); c < n {
+					n = c
+				}
+			}
+		}
+		return addSize(int64(len(s)), mulSize(n, int64(len(new)-len(old))))
+	case "format":
+		// 각 {} 필드가 가장 긴 인자로 바뀔 수 있다.
+		var arg int64
+		for _, v := range args {
+			if size := reprSize(v, limit); size > arg {
+				arg = size
+			}
+		}
+		for _, kv := range kwargs {
+			if size := reprSize(kv[1], limit); size > arg {
+				arg = size
+			}
+		}
+		return addSize(int64(len(s)), mulSize(int64(strings.Count(string(s), "{")), arg))
+	}
+	return 0
+}
+
+// valueSize returns the size of a string, or of the elements of a list or a
+// tuple, and 0 for other values.
+func valueSize(v starlark.Value) int64 {
+	switch v := v.(type) {
+	case starlark.String:
+		return int64(len(v))
+	case starlark.Bytes:
+		return int64(len(v))
+	case *starlark.List, starlark.Tuple:
+		return mulSize(int64(starlark.Len(v)), scriptElemSize)
+	}
+	return 0
+}
+
+// reprSize estimates the length of the string representation of v. It stops
+// once the length exceeds limit.
+func reprSize(v starlark.Value, limit int64) int64 {
+	w := reprWalker{limit: limit, path: make(map[starlark.Value]bool)}
+	w.walk(v)
+	return w.size
+}
+
+type reprWalker struct {
+	size  int64
+	limit int64
+	// 자기 자신을 포함하는 리스트, 딕셔너리를 한 번만 센다.
+	path map[starlark.Value]bool
+}
+
+func (w *reprWalker) walk(v starlark.Value) {
+	if w.size > w.limit {
+		return
+	}
+	switch v := v.(type) {
+	case starlark.String:
+		w.size = addSize(w.size, int64(len(v))+2)
+	case starlark.Bytes:
+		w.size = addSize(w.size, int64(len(v))+3)
+	case starlark.Int:
+		w.size = addSize(w.size, intBits(v)/3+2)
+	case starlark.Tuple:
+		for _, elem := range v {
+			w.size += 2
+			w.walk(elem)
+		}
+		w.size += 2
+	case *starlark.List, *starlark.Dict, *starlark.Set:
+		if w.path[v] {
+			w.size += 5
+			return
+		}
+		w.path[v] = true
+		defer delete(w.path, v)
+		if d, ok := v.(*starlark.Dict); ok {
+			for _, item := range d.Items() {
+				w.size += 4
+				w.walk(item[0])
+				w.walk(item[1])
+			}
+		} else {
+			iter := starlark.Iterate(v)
+			defer iter.Done()
+			var elem starlark.Value
+			for w.size <= w.limit && iter.Next(&elem) {
+				w.size += 2
+				w.walk(elem)
+			}
+		}
+		w.size += 2
+	default:
+		w.size = addSize(w.size, int64(len(v.String())))
+	}
+}
+
+// intBits returns the number of bits of an integer.
+func intBits(i starlark.Int) int64 {
+	if n, ok := i.Int64(); ok {
+		if n < 0 {
+			n = -n
+		}
+		return int64(bits.Len64(uint64(n)))
+	}
+	return int64(i.BigInt().BitLen())
+}
+
+// intCount returns a repetition count, saturated to math.MaxInt64.
+func intCount(i starlark.Int) int64 {
+	if n, ok := i.Int64(); ok {
+		if n < 0 {
+			return 0
+		}
+		return n
+	}
+	if i.Sign() < 0 {
+		return 0
+	}
+	return math.MaxInt64
+}
+
+func addSize(a, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+func mulSize(a, b int64) int64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	if a > math.MaxInt64/b {
+		return math.MaxInt64
+	}
+	return a * b
+}
+
+// checkReserved rejects scripts using the names of the checked builtins,
+// which could otherwise be redefined to skip the checks.
+func checkReserved(f *syntax.File) error {
+	var err error
+	syntax.Walk(f, func(n syntax.Node) bool {
+		if id, ok := n.(*syntax.Ident); ok && err == nil {
+			switch id.Name {
+			case scriptBinaryFn, scriptSetIndexFn, scriptSetAttrFn, scriptAttrFn:
+				err = fmt.Errorf("%s: %s is reserved", id.NamePos, id.Name)
+			}
+		}
+		return err == nil
+	})
+	return err
+}
+
+// rewriteAllocs rewrites the operations of the file that can grow a value
+// faster than one element per step into calls of the checked builtins.
+func rewriteAllocs(f *syntax.File) {
+	rewriteStmts(f.Stmts)
+}
+
+func rewriteStmts(stmts []syntax.Stmt) {
+	for i, stmt := range stmts {
+		stmts[i] = rewriteStmt(stmt)
+	}
+}
+
+func rewriteStmt(stmt syntax.Stmt) syntax.Stmt {
+	switch s := stmt.(type) {
+	case *syntax.AssignStmt:
+		s.RHS = rewriteExpr(s.RHS)
+		op, checked := scriptCheckedOps[s.Op]
+		if !checked || s.Op == op {
+			s.LHS = rewriteTarget(s.LHS)
+			return s
+		}
+		// 복합 대입: x op= y
+		name := opName(op) + "="
+		switch lhs := s.LHS.(type) {
+		case *syntax.Ident:
+			read := &syntax.Ident{NamePos: lhs.NamePos, Name: lhs.Name}
+			s.Op = syntax.EQ
+			s.RHS = callChecked(scriptBinaryFn, s.OpPos, opLiteral(name, s.OpPos), read, s.RHS)
+			return s
+		case *syntax.IndexExpr:
+			return &syntax.ExprStmt{X: callChecked(scriptSetIndexFn, s.OpPos,
+				rewriteExpr(lhs.X), rewriteExpr(lhs.Y), opLiteral(name, s.OpPos), s.RHS)}
+		case *syntax.DotExpr:
+			return &syntax.ExprStmt{X: callChecked(scriptSetAttrFn, s.OpPos,
+				rewriteExpr(lhs.X), opLiteral(lhs.Name.Name, lhs.NamePos), opLiteral(name, s.OpPos), s.RHS)}
+		}
+		s.LHS = rewriteTarget(s.LHS)
+	case *syntax.DefStmt:
+		rewriteParams(s.Params)
+		rewriteStmts(s.Body)
+	case *syntax.ExprStmt:
+		s.X = rewriteExpr(s.X)
+	case *syntax.ForStmt:
+		s.Vars = rewriteTarget(s.Vars)
+		s.X = rewriteExpr(s.X)
+		rewriteStmts(s.Body)
+	case *syntax.WhileStmt:
+		s.Cond = rewriteExpr(s.Cond)
+		rewriteStmts(s.Body)
+	case *syntax.IfStmt:
+		s.Cond = rewriteExpr(s.Cond)
+		rewriteStmts(s.True)
+		rewriteStmts(s.False)
+	case *syntax.ReturnStmt:
+		if s.Result != nil {
+			s.Result = rewriteExpr(s.Result)
+		}
+	}
+	return stmt
+}
+
+// rewriteTarget rewrites the expressions evaluated by an assignment target,
+// e.g. the operands of x[i] and x.f, but not the target itself.
+func rewriteTarget(e syntax.Expr) syntax.Expr {
+	switch t := e.(type) {
+	case *syntax.IndexExpr:
+		t.X = rewriteExpr(t.X)
+		t.Y = rewriteExpr(t.Y)
+	case *syntax.DotExpr:
+		t.X = rewriteExpr(t.X)
+	case *syntax.TupleExpr:
+		for i, elem := range t.List {
+			t.List[i] = rewriteTarget(elem)
+		}
+	case *syntax.ListExpr:
+		for i, elem := range t.List {
+			t.List[i] = rewriteTarget(elem)
+		}
+	case *syntax.ParenExpr:
+		t.X = rewriteTarget(t.X)
+	}
+	return e
+}
+
+// rewriteParams rewrites the default values of parameters and the values of
+// keyword arguments, e.g. f(x=a+b).
+func rewriteParams(params []syntax.Expr) {
+	for i, param := range params {
+		switch p := param.(type) {
+		case *syntax.BinaryExpr:
+			if p.Op == syntax.EQ {
+				p.Y = rewriteExpr(p.Y)
+				continue
+			}
+		case *syntax.UnaryExpr:
+			if p.X != nil {
+				p.X = rewriteExpr(p.X)
+			}
+			continue
+		case *syntax.Ident:
+			continue
+		}
+		params[i] = rewriteExpr(param)
+	}
+}
+
+func rewriteExpr(e syntax.Expr) syntax.Expr {
+	switch x := e.(type) {
+	case *syntax.BinaryExpr:
+		x.X = rewriteExpr(x.X)
+		x.Y = rewriteExpr(x.Y)
+		if op, ok := scriptCheckedOps[x.Op]; ok && op == x.Op {
+			return callChecked(scriptBinaryFn, x.OpPos, opLiteral(opName(op), x.OpPos), x.X, x.Y)
+		}
+	case *syntax.CallExpr:
+		x.Fn = rewriteExpr(x.Fn)
+		rewriteParams(x.Args)
+	case *syntax.DotExpr:
+		x.X = rewriteExpr(x.X)
+		if scriptCheckedMethods[x.Name.Name] {
+			return callChecked(scriptAttrFn, x.Dot, x.X, opLiteral(x.Name.Name, x.NamePos))
+		}
+	case *syntax.Comprehension:
+		x.Body = rewriteExpr(x.Body)
+		for _, clause := range x.Clauses {
+			switch c := clause.(type) {
+			case *syntax.ForClause:
+				c.Vars = rewriteTarget(c.Vars)
+				c.X = rewriteExpr(c.X)
+			case *syntax.IfClause:
+				c.Cond = rewriteExpr(c.Cond)
+			}
+		}
+	case *syntax.CondExpr:
+		x.Cond = rewriteExpr(x.Cond)
+		x.True = rewriteExpr(x.True)
+		x.False = rewriteExpr(x.False)
+	case *syntax.DictExpr:
+		for _, entry := range x.List {
+			entry := entry.(*syntax.DictEntry)
+			entry.Key = rewriteExpr(entry.Key)
+			entry.Value = rewriteExpr(entry.Value)
+		}
+	case *syntax.IndexExpr:
+		x.X = rewriteExpr(x.X)
+		x.Y = rewriteExpr(x.Y)
+	case *syntax.LambdaExpr:
+		rewriteParams(x.Params)
+		x.Body = rewriteExpr(x.Body)
+	case *syntax.ListExpr:
+		for i, elem := range x.List {
+			x.List[i] = rewriteExpr(elem)
+		}
+	case *syntax.TupleExpr:
+		for i, elem := range x.List {
+			x.List[i] = rewriteExpr(elem)
+		}
+	case *syntax.ParenExpr:
+		x.X = rewriteExpr(x.X)
+	case *syntax.SliceExpr:
+		x.X = rewriteExpr(x.X)
+		for _, bound := range []*syntax.Expr{&x.Lo, &x.Hi, &x.Step} {
+			if *bound != nil {
+				*bound = rewriteExpr(*bound)
+			}
+		}
+	case *syntax.UnaryExpr:
+		if x.X != nil {
+			x.X = rewriteExpr(x.X)
+		}
+	}
+	return e
+}
+
+func callChecked(fn string, pos syntax.Position, args ...syntax.Expr) *syntax.CallExpr {
+	return &syntax.CallExpr{
+		Fn:     &syntax.Ident{NamePos: pos, Name: fn},
+		Lparen: pos,
+		Args:   args,
+		Rparen: pos,
+	}
+}
+
+func opLiteral(s string, pos syntax.Position) *syntax.Literal {
+	return &syntax.Literal{Token: syntax.STRING, TokenPos: pos, Raw: strconv.Quote(s), Value: s}
+}
+
+func opName(op syntax.Token) string {
+	switch op {
+	case syntax.PLUS:
+		return "+"
+	case syntax.STAR:
+		return "*"
+	}
+	return "%"
+}
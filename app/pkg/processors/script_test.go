@@ -0,0 +1,287 @@
+package processors_test
+
+import (
+	"context"
+	"errors"
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/processors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testScript = `
+def process(event):
+    value = event["value"]
+    if value.get("status") == "test":
+        return None
+    if "items" in value:
+        events = []
+        for item in value["items"]:
+            e = dict(event)
+            e["value"] = item
+            e["doc_id"] = "%s-%d" % (event["key"], item["sku"])
+            events.append(e)
+        return events
+    value["amount"] = value["amount"] * 2
+    event["metadata"] = {"source_topic": event["source"]["topic"]}
+    event["headers"] = {"processed": True}
+    return event
+`
+
+func TestScript_Process(t *testing.T) {
+	s, err := processors.NewScript(processors.ScriptCfg{Source: testScript})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("modify", func(t *testing.T) {
+		e := &payloads.Event{
+			Source: payloads.EventSource{Type: "kafka", Topic: "purchases"},
+			Key:    "k",
+			Value:  jsonObj{"amount": float64(21)},
+		}
+		p, err := s.Process(context.Background(), e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := p.(*payloads.Event)
+		if !reflect.DeepEqual(out.Value, jsonObj{"amount": float64(42)}) {
+			t.Errorf("unexpected value %v", out.Value)
+		}
+		if !reflect.DeepEqual(out.Metadata, jsonObj{"source_topic": "purchases"}) {
+			t.Errorf("unexpected metadata %v", out.Metadata)
+		}
+		if out.Headers["processed"] != "true" {
+			t.Errorf("unexpected headers %v", out.Headers)
+		}
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		p, err := s.Process(context.Background(), &payloads.Event{Value: jsonObj{"status": "test"}})
+		if err != nil || p != nil {
+			t.Errorf("expected the event to be dropped, got %v, %v", p, err)
+		}
+	})
+
+	t.Run("split", func(t *testing.T) {
+		ack := &payloads.Ack{}
+		e := &payloads.Event{
+			Key:   "order",
+			Value: jsonObj{"items": []interface{}{jsonObj{"sku": float64(1)}, jsonObj{"sku": float64(2)}}},
+			Ack:   ack,
+		}
+		p, err := s.Process(context.Background(), e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		batch, ok := p.(payloads.Batch)
+		if !ok || len(batch) != 2 {
+			t.Fatalf("expected a batch of 2 events, got %v", p)
+		}
+		for i, item := range batch {
+			out := item.(*payloads.Event)
+			if out.Ack != ack {
+				t.Errorf("event %d: expected the ack of the input", i)
+			}
+			if want := []string{"order-1", "order-2"}[i]; out.DocID != want {
+				t.Errorf("event %d: expected doc_id %s, got %s", i, want, out.DocID)
+			}
+		}
+	})
+}
+
+func TestScript_Limits(t *testing.T) {
+	loop := `
+def process(event):
+    n = 0
+    for i in range(100000000):
+        n += i
+    return event
+`
+	testCases := []struct {
+		desc string
+		cfg  processors.ScriptCfg
+	}{
+		{desc: "steps", cfg: processors.ScriptCfg{Source: loop, MaxSteps: 1000}},
+		{desc: "timeout", cfg: processors.ScriptCfg{Source: loop, TimeoutMs: 10, MaxSteps: 1 << 40}},
+		{desc: "events", cfg: processors.ScriptCfg{Source: "def process(event):\n    return [event] * 3", MaxEvents: 2}},
+		{desc: "output", cfg: processors.ScriptCfg{Source: "def process(event):\n    event[\"key\"] = \"x\" * 100\n    return event", MaxOutputBytes: 64}},
+		{desc: "repeat", cfg: processors.ScriptCfg{Source: "def process(event):\n    s = \"x\" * 100000000\n    return event", MaxAllocBytes: 1 << 20}},
+		{desc: "concat", cfg: processors.ScriptCfg{Source: "def process(event):\n    s = \"x\"\n    for i in range(64):\n        s = s + s\n    return event", MaxAllocBytes: 1 << 20}},
+		{desc: "augmented concat", cfg: processors.ScriptCfg{Source: "def process(event):\n    l = [1]\n    for i in range(64):\n        l += l\n    return event", MaxAllocBytes: 1 << 20}},
+		{desc: "extend", cfg: processors.ScriptCfg{Source: "def process(event):\n    l = [1]\n    for i in range(64):\n        l.extend(l)\n    return event", MaxAllocBytes: 1 << 20}},
+		{desc: "join", cfg: processors.ScriptCfg{Source: "def process(event):\n    s = \"x\" * 1000\n    s = \",\".join([s] * 10000)\n    return event", MaxAllocBytes: 1 << 20}},
+		{desc: "list", cfg: processors.ScriptCfg{Source: "def process(event):\n    l = list(range(1000000000))\n    return event", MaxAllocBytes: 1 << 20}},
+		{desc: "str", cfg: processors.ScriptCfg{Source: "def process(event):\n    l = [\"x\" * 1000] * 10000\n    s = str(l)\n    return event", MaxAllocBytes: 1 << 20}},
+		{desc: "item", cfg: processors.ScriptCfg{Source: "def process(event):\n    event[\"key\"] = \"x\"\n    for i in range(64):\n        event[\"key\"] *= 2\n    return event", MaxAllocBytes: 1 << 20}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			s, err := processors.NewScript(tC.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = s.Process(context.Background(), &payloads.Event{Value: jsonObj{}})
+			if !errors.Is(err, processors.ErrScriptLimit) {
+				t.Errorf("expected ErrScriptLimit, got %v", err)
+			}
+		})
+	}
+}
+
+func TestScript_Operators(t *testing.T) {
+	// 할당 검사를 거치도록 재작성된 연산자도 원래와 같은 결과를 낸다.
+	src := `
+def process(event):
+    l = [1]
+    alias = l
+    l += [2]
+    l.extend((3,))
+    d = {"n": 1, "s": "a"}
+    d["n"] += 2
+    d["s"] *= 3
+    n = 7
+    n %= 4
+    event["value"] = {
+        "list": alias,
+        "n": d["n"] * n,
+        "s": d["s"] + "-" + "%s/%d" % ("b", 5),
+        "join": ",".join(["x", "y"]),
+        "repr": str([1, "a"]),
+        "json": json.encode({"a": [1]}),
+        "default": getattr(event, "missing", "none"),
+        "kwargs": dict(a = 1 + 1)["a"],
+    }
+    return event
+`
+	s, err := processors.NewScript(processors.ScriptCfg{Source: src})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := s.Process(context.Background(), &payloads.Event{Value: jsonObj{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := jsonObj{
+		"list":    []interface{}{float64(1), float64(2), float64(3)},
+		"n":       float64(9),
+		"s":       "aaa-b/5",
+		"join":    "x,y",
+		"repr":    `[1, "a"]`,
+		"json":    `{"a":[1]}`,
+		"default": "none",
+		"kwargs":  float64(2),
+	}
+	if got := p.(*payloads.Event).Value; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// 재작성된 구문마다 원래와 같은 결과를 내고, 반복해서 만든 값이 할당 예산에
+// 누적되는지 확인한다. 한 번 반복할 때 시험하는 구문만으로 1KB 이상을 만들므로
+// 2000 번 반복하면 그 구문이 검사될 때만 1MB 예산을 넘는다.
+func TestScript_AllocRewrite(t *testing.T) {
+	testCases := []struct {
+		desc string
+		// s 는 1000 자 문자열, l 은 원소가 1000 개인 리스트, 결과는 len(r)
+		stmt string
+		want int
+	}{
+		{desc: "string concat", stmt: "r = s + s", want: 2000},
+		{desc: "string repetition", stmt: "r = s * 2", want: 2000},
+		{desc: "list repetition", stmt: "r = l * 2", want: 2000},
+		{desc: "int times list", stmt: "r = 2 * l", want: 2000},
+		{desc: "format operator", stmt: `r = "%s%s" % (s, s)`, want: 2000},
+		{desc: "augmented concat", stmt: "r = s\nr += s", want: 2000},
+		{desc: "augmented list concat", stmt: "r = []\nr += l", want: 1000},
+		{desc: "augmented repetition", stmt: "r = s\nr *= 2", want: 2000},
+		{desc: "augmented format", stmt: "r = \"%s%s\"\nr %= (s, s)", want: 2000},
+		{desc: "augmented dict item", stmt: "d = {\"k\": s}\nd[\"k\"] += s\nr = d[\"k\"]", want: 2000},
+		{desc: "augmented list item", stmt: "a = [s]\na[0] *= 2\nr = a[0]", want: 2000},
+		{desc: "join", stmt: `r = ",".join([s, s])`, want: 2001},
+		{desc: "replace", stmt: `r = s.replace("x", "xy")`, want: 2000},
+		{desc: "format method", stmt: `r = "{}{}".format(s, s)`, want: 2000},
+		{desc: "extend", stmt: "r = []\nr.extend(l)", want: 1000},
+		{desc: "method value", stmt: "r = []\next = r.extend\next(l)", want: 1000},
+		{desc: "getattr", stmt: "r = []\ngetattr(r, \"extend\")(l)", want: 1000},
+		{desc: "str", stmt: "r = str([s])", want: 1004},
+		{desc: "repr", stmt: "r = repr(s)", want: 1002},
+		{desc: "json encode", stmt: "r = json.encode([s])", want: 1004},
+		{desc: "json encode string", stmt: "r = json.encode(s)", want: 1002},
+		{desc: "list", stmt: "r = list(l)", want: 1000},
+		{desc: "tuple", stmt: "r = tuple(l)", want: 1000},
+		{desc: "comprehension body", stmt: "r = [s + s for c in [0]][0]", want: 2000},
+		{desc: "comprehension iterable", stmt: "r = [c for c in [s + s]][0]", want: 2000},
+		{desc: "comprehension condition", stmt: "r = [c for c in [s] if s + s][0]", want: 1000},
+		{desc: "conditional", stmt: `r = s + s if s else ""`, want: 2000},
+		{desc: "dict value", stmt: `r = {"k": s + s}["k"]`, want: 2000},
+		{desc: "dict key", stmt: "r = {s + s: 0}.keys()[0]", want: 2000},
+		{desc: "list element", stmt: "r = [s + s][0]", want: 2000},
+		{desc: "tuple element", stmt: "r = (s + s, 0)[0]", want: 2000},
+		{desc: "index", stmt: "r = [s][len(s + s) - 2000]", want: 1000},
+		{desc: "slice", stmt: "r = (s + s)[1:]", want: 1999},
+		{desc: "slice bound", stmt: "r = s[:len(s + s)]", want: 1000},
+		{desc: "unary", stmt: "r = s[-len(s + s):]", want: 1000},
+		{desc: "lambda body", stmt: "f = lambda: s + s\nr = f()", want: 2000},
+		{desc: "lambda default", stmt: "f = lambda a = s + s: a\nr = f()", want: 2000},
+		{desc: "def body", stmt: "def f():\n    return s + s\nr = f()", want: 2000},
+		{desc: "def default", stmt: "def f(a = s + s):\n    return a\nr = f()", want: 2000},
+		{desc: "keyword argument", stmt: "r = dict(k = s + s)[\"k\"]", want: 2000},
+		{desc: "variadic argument", stmt: "r = min(*[[s + s]])", want: 2000},
+		{desc: "keyword arguments", stmt: "r = dict(**{\"k\": s + s})[\"k\"]", want: 2000},
+		{desc: "assignment target", stmt: "d = {}\nd[s + s] = 0\nr = d.keys()[0]", want: 2000},
+		{desc: "tuple assignment target", stmt: "d = {}\nd[s + s], n = 0, 0\nr = d.keys()[0]", want: 2000},
+		{desc: "for iterable", stmt: "for c in [s + s]:\n    r = c", want: 2000},
+		{desc: "if condition", stmt: "if s + s:\n    r = s", want: 1000},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			src := "def process(event):\n" +
+				"    s = \"x\" * 1000\n" +
+				"    l = [0] * 1000\n" +
+				"    for i in range(event[\"value\"][\"n\"]):\n" +
+				"        " + strings.ReplaceAll(tC.stmt, "\n", "\n        ") + "\n" +
+				"    event[\"value\"] = {\"len\": len(r)}\n" +
+				"    return event\n"
+			s, err := processors.NewScript(processors.ScriptCfg{Source: src, MaxAllocBytes: 1 << 20, TimeoutMs: 10000})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p, err := s.Process(context.Background(), &payloads.Event{Value: jsonObj{"n": 1}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.(*payloads.Event).Value["len"]; got != float64(tC.want) {
+				t.Errorf("expected len %d, got %v", tC.want, got)
+			}
+
+			_, err = s.Process(context.Background(), &payloads.Event{Value: jsonObj{"n": 2000}})
+			if !errors.Is(err, processors.ErrScriptLimit) || !strings.Contains(err.Error(), "max_alloc_bytes") {
+				t.Errorf("expected the max_alloc_bytes limit, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewScript_Invalid(t *testing.T) {
+	testCases := []struct {
+		desc string
+		cfg  processors.ScriptCfg
+	}{
+		{desc: "no source", cfg: processors.ScriptCfg{}},
+		{desc: "syntax error", cfg: processors.ScriptCfg{Source: "def process(event)"}},
+		{desc: "missing function", cfg: processors.ScriptCfg{Source: "def other(event):\n    return event"}},
+		{desc: "reserved name", cfg: processors.ScriptCfg{Source: "__binary__ = None\ndef process(event):\n    return event"}},
+		{desc: "load", cfg: processors.ScriptCfg{Source: "load(\"os.star\", \"os\")\ndef process(event):\n    return event"}},
+		{desc: "top-level limit", cfg: processors.ScriptCfg{Source: "s = \"x\" * 100000000\ndef process(event):\n    return event", MaxAllocBytes: 1 << 20}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if _, err := processors.NewScript(tC.cfg); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
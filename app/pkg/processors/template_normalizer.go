@@ -0,0 +1,242 @@
+package processors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"event-data-pipeline/pkg/jsonpath"
+	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/payloads"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var _ Processor = new(TemplateNormalizer)
+
+func init() {
+	Register("template_normalizer", NewTemplateNormalizerProcessor)
+}
+
+// TemplateNormalizerCfg holds the expressions used to build the index, the
+// document ID and the data of an event. Each expression is either a JSONPath
+// starting with `$` or a Go template, evaluated against the fields of the
+// event (see payloads.Event.Fields).
+//
+//	index: 'event-data-{{ date "01-02-2006" .timestamp }}'
+//	doc_id: '{{ .source.queue }}.{{ .value.id }}'
+//	data: $.value
+type TemplateNormalizerCfg struct {
+	// 필수
+	Index string `json:"index,omitempty"`
+	// 없으면 DocID 를 설정하지 않는다.
+	DocID string `json:"doc_id,omitempty"`
+	// 없으면 Value 를 json 으로 변환한다.
+	Data string `json:"data,omitempty"`
+}
+
+// TemplateNormalizer normalizes events according to the expressions of its
+// configuration, so each topic or queue can have its own index and document
+// ID without a dedicated normalizer.
+type TemplateNormalizer struct {
+	index *fieldExpr
+	docID *fieldExpr
+	data  *fieldExpr
+}
+
+func NewTemplateNormalizerProcessor(config jsonObj) Processor {
+	var cfg TemplateNormalizerCfg
+	// 바이트로 변환
+	cfgByte, _ := json.Marshal(config)
+
+	// 설정파일 Struct 으로 Load
+	json.Unmarshal(cfgByte, &cfg)
+
+	tn, err := NewTemplateNormalizer(cfg)
+	if err != nil {
+		logger.Fatalf("error in creating template normalizer: %v", err)
+	}
+	return tn
+}
+
+// NewTemplateNormalizer compiles the expressions of the configuration.
+func NewTemplateNormalizer(cfg TemplateNormalizerCfg) (*TemplateNormalizer, error) {
+	if cfg.Index == "" {
+		return nil, errors.New("index is required")
+	}
+	tn := &TemplateNormalizer{}
+	var err error
+	if tn.index, err = compileField("index", cfg.Index); err != nil {
+		return nil, err
+	}
+	if tn.docID, err = compileField("doc_id", cfg.DocID); err != nil {
+		return nil, err
+	}
+	if tn.data, err = compileField("data", cfg.Data); err != nil {
+		return nil, err
+	}
+	return tn, nil
+}
+
+// Process implements Processor
+func (tn *TemplateNormalizer) Process(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+	e, err := payloads.AsEvent(p)
+	if err != nil {
+		return nil, err
+	}
+	fields := e.Fields()
+
+	// 인덱스 생성
+	index, err := tn.index.text(fields)
+	if err != nil {
+		return nil, err
+	}
+	if index == "" {
+		return nil, errors.New("index: evaluated to an empty string")
+	}
+	e.Index = index
+
+	// 식별자 생성
+	if tn.docID != nil {
+		if e.DocID, err = tn.docID.text(fields); err != nil {
+			return nil, err
+		}
+	}
+
+	// 데이터 생성
+	if tn.data == nil {
+		e.Data, err = json.Marshal(e.Value)
+	} else {
+		e.Data, err = tn.data.bytes(fields)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// fieldExpr 는 JSONPath 나 Go 템플릿 중 하나로 컴파일된 설정 값이다.
+type fieldExpr struct {
+	name string
+	path jsonpath.Path
+	tmpl *template.Template
+}
+
+// compileField returns nil for an empty expression.
+func compileField(name, expr string) (*fieldExpr, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	if jsonpath.IsPath(expr) {
+		path, err := jsonpath.Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return &fieldExpr{name: name, path: path}, nil
+	}
+	// 없는 필드를 참조하면 빈 값 대신 에러를 반환한다.
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &fieldExpr{name: name, tmpl: tmpl}, nil
+}
+
+// text evaluates the expression to a string.
+func (f *fieldExpr) text(fields jsonObj) (string, error) {
+	if f.tmpl != nil {
+		out, err := f.execute(fields)
+		return string(out), err
+	}
+	v, err := f.lookup(fields)
+	if err != nil {
+		return "", err
+	}
+	return stringify(v), nil
+}
+
+// bytes evaluates the expression to the document data. A JSONPath selects
+// the value to encode as json, a template renders the data as is.
+func (f *fieldExpr) bytes(fields jsonObj) ([]byte, error) {
+	if f.tmpl != nil {
+		return f.execute(fields)
+	}
+	v, err := f.lookup(fields)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func (f *fieldExpr) lookup(fields jsonObj) (interface{}, error) {
+	v, ok := f.path.Get(fields)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s not found", f.name, f.path)
+	}
+	return v, nil
+}
+
+func (f *fieldExpr) execute(fields jsonObj) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := f.tmpl.Execute(&buf, templateValue(fields)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// templateNumber is a json number in template data. Templates print values
+// with fmt, which formats float64 numbers of 1e21 and up, and whole numbers
+// of 1e6 and up with %v, in exponent form; templateNumber prints them in full.
+type templateNumber float64
+
+func (n templateNumber) String() string {
+	return strconv.FormatFloat(float64(n), 'f', -1, 64)
+}
+
+// templateValue copies the objects and arrays of v with json numbers
+// replaced by templateNumber.
+func templateValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case float64:
+		return templateNumber(val)
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(val))
+		for k, field := range val {
+			obj[k] = templateValue(field)
+		}
+		return obj
+	case []interface{}:
+		arr := make([]interface{}, len(val))
+		for i, item := range val {
+			arr[i] = templateValue(item)
+		}
+		return arr
+	}
+	return v
+}
+
+// 템플릿에서 사용할 수 있는 함수
+var templateFuncs = template.FuncMap{
+	// json 으로 변환
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	// 시간을 layout 형식으로 변환
+	"date": func(layout string, v interface{}) (string, error) {
+		t, ok := v.(time.Time)
+		if !ok {
+			return "", fmt.Errorf("date: expected a time, got %T", v)
+		}
+		return t.Format(layout), nil
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	// 파이프라인으로 넘겨받은 s 에서 old 를 new 로 변경
+	"replace": func(old, new, s string) string {
+		return strings.ReplaceAll(s, old, new)
+	},
+}
@@ -0,0 +1,127 @@
+package processors_test
+
+import (
+	"context"
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/processors"
+	"testing"
+	"time"
+)
+
+func TestTemplateNormalizer_Process(t *testing.T) {
+	ts := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	event := func() *payloads.Event {
+		return &payloads.Event{
+			Source:    payloads.EventSource{Type: payloads.SOURCE_KAFKA, Topic: "purchases", Partition: 1, Offset: 42},
+			Key:       "user-1",
+			Value:     jsonObj{"id": float64(7), "email": "a@b.c", "items": []interface{}{"x"}},
+			Timestamp: ts,
+		}
+	}
+	testCases := []struct {
+		desc    string
+		cfg     processors.TemplateNormalizerCfg
+		index   string
+		docID   string
+		data    string
+		wantErr bool
+	}{
+		{
+			desc: "templates",
+			cfg: processors.TemplateNormalizerCfg{
+				Index: `{{ .source.topic }}-{{ date "01-02-2006" .timestamp }}`,
+				DocID: "{{ .key }}.{{ .source.topic }}.{{ .source.partition }}.{{ .source.offset }}",
+				Data:  `{"email":{{ json .value.email }}}`,
+			},
+			index: "purchases-03-04-2022",
+			docID: "user-1.purchases.1.42",
+			data:  `{"email":"a@b.c"}`,
+		},
+		{
+			desc: "jsonpath",
+			cfg: processors.TemplateNormalizerCfg{
+				Index: "$.source.topic",
+				DocID: "$.value.id",
+				Data:  "$.value.items",
+			},
+			index: "purchases",
+			docID: "7",
+			data:  `["x"]`,
+		},
+		{
+			desc:  "default data",
+			cfg:   processors.TemplateNormalizerCfg{Index: "{{ .source.topic | upper }}"},
+			index: "PURCHASES",
+			data:  `{"email":"a@b.c","id":7,"items":["x"]}`,
+		},
+		{
+			desc:    "missing jsonpath field",
+			cfg:     processors.TemplateNormalizerCfg{Index: "$.value.missing"},
+			wantErr: true,
+		},
+		{
+			desc:    "missing template field",
+			cfg:     processors.TemplateNormalizerCfg{Index: "events", DocID: "{{ .value.missing }}"},
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			tn, err := processors.NewTemplateNormalizer(tC.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := tn.Process(context.Background(), event())
+			if tC.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			index, docID, data := p.Out()
+			if index != tC.index || docID != tC.docID || string(data) != tC.data {
+				t.Errorf("expected (%s, %s, %s), got (%s, %s, %s)", tC.index, tC.docID, tC.data, index, docID, data)
+			}
+		})
+	}
+}
+
+func TestTemplateNormalizer_LargeNumbers(t *testing.T) {
+	event := &payloads.Event{
+		Source: payloads.EventSource{Type: payloads.SOURCE_KAFKA, Topic: "purchases", Partition: 3, Offset: 12345678},
+		Value:  jsonObj{"id": float64(98765432), "amount": 2500000.5, "items": []interface{}{float64(1e7)}},
+	}
+	// 1e6 이상의 숫자도 지수 형식이 아닌 그대로 출력되어야 한다.
+	tn, err := processors.NewTemplateNormalizer(processors.TemplateNormalizerCfg{
+		Index: "$.source.offset",
+		DocID: "{{ .source.partition }}.{{ .source.offset }}.{{ .value.id }}.{{ index .value.items 0 }}",
+		Data:  `{"amount":{{ .value.amount }},"value":{{ json .value }}}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := tn.Process(context.Background(), event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, docID, data := p.Out()
+	wantData := `{"amount":2500000.5,"value":{"amount":2500000.5,"id":98765432,"items":[10000000]}}`
+	if index != "12345678" || docID != "3.12345678.98765432.10000000" || string(data) != wantData {
+		t.Errorf("expected (12345678, 3.12345678.98765432.10000000, %s), got (%s, %s, %s)", wantData, index, docID, data)
+	}
+}
+
+func TestNewTemplateNormalizer_InvalidConfig(t *testing.T) {
+	for _, cfg := range []processors.TemplateNormalizerCfg{
+		{},
+		{Index: "$.value["},
+		{Index: "events", DocID: "{{ .key "},
+	} {
+		if _, err := processors.NewTemplateNormalizer(cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package processors
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"event-data-pipeline/pkg/jsonpath"
+	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/payloads"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ Processor = new(Transform)
+
+func init() {
+	Register("transform", NewTransformProcessor)
+}
+
+// transform 연산 종류
+const (
+	TRANSFORM_RENAME  = "rename"
+	TRANSFORM_DROP    = "drop"
+	TRANSFORM_DEFAULT = "default"
+	TRANSFORM_CAST    = "cast"
+	TRANSFORM_HASH    = "hash"
+	TRANSFORM_MASK    = "mask"
+	TRANSFORM_FLATTEN = "flatten"
+)
+
+// cast 대상 타입
+const (
+	CAST_STRING  = "string"
+	CAST_NUMBER  = "number"
+	CAST_INT     = "int"
+	CAST_BOOL    = "bool"
+	CAST_RFC3339 = "rfc3339"
+)
+
+const (
+	DEFAULT_MASK_CHAR         = "*"
+	DEFAULT_FLATTEN_SEPARATOR = "."
+)
+
+// TransformCfg lists the operations applied, in order, to the Value of each
+// event.
+//
+//	operations:
+//	  - op: rename
+//	    field: user_name
+//	    to: user.name
+//	  - op: drop
+//	    fields: [password]
+//	  - op: default
+//	    field: currency
+//	    value: KRW
+//	  - op: cast
+//	    field: created_at
+//	    type: rfc3339
+//	    unit: ms
+//	  - op: hash
+//	    field: email
+//	  - op: mask
+//	    field: phone
+//	    keep_last: 4
+//	  - op: flatten
+type TransformCfg struct {
+	Operations []TransformOpCfg `json:"operations,omitempty"`
+}
+
+// TransformOpCfg configures one operation. Fields are paths into the Value,
+// written either as `user.email` or as a JSONPath such as `$.user.email`.
+type TransformOpCfg struct {
+	Op     string   `json:"op,omitempty"`
+	Field  string   `json:"field,omitempty"`
+	Fields []string `json:"fields,omitempty"`
+
+	// rename: 새 필드 경로
+	To string `json:"to,omitempty"`
+	// default: 필드가 없거나 null 일 때 설정할 값
+	Value interface{} `json:"value,omitempty"`
+	// cast: string, number, int, bool, rfc3339
+	Type string `json:"type,omitempty"`
+	// cast rfc3339: epoch 의 단위 s, ms, us, ns (기본값 s)
+	Unit string `json:"unit,omitempty"`
+	// hash: sha256 에 앞서 붙이는 값
+	Salt string `json:"salt,omitempty"`
+	// mask: 가리지 않고 남길 앞뒤 글자 수와 가릴 때 쓰는 문자
+	KeepFirst int    `json:"keep_first,omitempty"`
+	KeepLast  int    `json:"keep_last,omitempty"`
+	MaskChar  string `json:"mask_char,omitempty"`
+	// flatten: 합쳐진 키의 구분자
+	Separator string `json:"separator,omitempty"`
+}
+
+// Transform applies declarative operations to the Value of events.
+type Transform struct {
+	ops []transformOp
+}
+
+// transformOp 는 Value 를 변경하는 하나의 연산이다.
+type transformOp func(value jsonObj) error
+
+func NewTransformProcessor(config jsonObj) Processor {
+	var cfg TransformCfg
+	// 바이트로 변환
+	cfgByte, _ := json.Marshal(config)
+
+	// 설정파일 Struct 으로 Load
+	json.Unmarshal(cfgByte, &cfg)
+
+	t, err := NewTransform(cfg)
+	if err != nil {
+		logger.Fatalf("error in creating transform: %v", err)
+	}
+	return t
+}
+
+// NewTransform compiles the operations of the configuration.
+func NewTransform(cfg TransformCfg) (*Transform, error) {
+	t := &Transform{}
+	for i, opCfg := range cfg.Operations {
+		op, err := compileTransformOp(opCfg)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, opCfg.Op, err)
+		}
+		t.ops = append(t.ops, op)
+	}
+	return t, nil
+}
+
+// Process implements Processor
+func (t *Transform) Process(ctx context.Context, p payloads.Payload) (payloads.Payload, error) {
+	e, err := payloads.AsEvent(p)
+	if err != nil {
+		return nil, err
+	}
+	if e.Value == nil {
+		e.Value = make(jsonObj)
+	}
+	for _, op := range t.ops {
+		if err := op(e.Value); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+func compileTransformOp(cfg TransformOpCfg) (transformOp, error) {
+	fields := cfg.Fields
+	if cfg.Field != "" {
+		fields = append([]string{cfg.Field}, fields...)
+	}
+	paths := make([]jsonpath.Path, len(fields))
+	for i, f := range fields {
+		path, err := fieldPath(f)
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = path
+	}
+	if len(paths) == 0 && cfg.Op != TRANSFORM_FLATTEN {
+		return nil, errors.New("field is required")
+	}
+
+	switch cfg.Op {
+	case TRANSFORM_RENAME:
+		if len(paths) != 1 || cfg.To == "" {
+			return nil, errors.New("exactly one field and to are required")
+		}
+		to, err := fieldPath(cfg.To)
+		if err != nil {
+			return nil, err
+		}
+		return renameOp(paths[0], to), nil
+	case TRANSFORM_DROP:
+		return dropOp(paths), nil
+	case TRANSFORM_DEFAULT:
+		return eachField(paths, func(v interface{}, ok bool) (interface{}, bool, error) {
+			if ok && v != nil {
+				return nil, false, nil
+			}
+			return cfg.Value, true, nil
+		}), nil
+	case TRANSFORM_CAST:
+		cast, err := caster(cfg.Type, cfg.Unit)
+		if err != nil {
+			return nil, err
+		}
+		return eachExisting(paths, cast), nil
+	case TRANSFORM_HASH:
+		return eachExisting(paths, func(v interface{}) (interface{}, error) {
+			sum := sha256.Sum256([]byte(cfg.Salt + stringify(v)))
+			return hex.EncodeToString(sum[:]), nil
+		}), nil
+	case TRANSFORM_MASK:
+		maskChar := cfg.MaskChar
+		if maskChar == "" {
+			maskChar = DEFAULT_MASK_CHAR
+		}
+		if cfg.KeepFirst < 0 || cfg.KeepLast < 0 {
+			return nil, errors.New("keep_first and keep_last must not be negative")
+		}
+		return eachExisting(paths, func(v interface{}) (interface{}, error) {
+			return mask(stringify(v), cfg.KeepFirst, cfg.KeepLast, maskChar), nil
+		}), nil
+	case TRANSFORM_FLATTEN:
+		sep := cfg.Separator
+		if sep == "" {
+			sep = DEFAULT_FLATTEN_SEPARATOR
+		}
+		return flattenOp(paths, sep), nil
+	}
+	return nil, fmt.Errorf("invalid op %q. Must be one of: %s", cfg.Op, strings.Join([]string{
+		TRANSFORM_RENAME, TRANSFORM_DROP, TRANSFORM_DEFAULT, TRANSFORM_CAST, TRANSFORM_HASH, TRANSFORM_MASK, TRANSFORM_FLATTEN,
+	}, ", "))
+}
+
+// fieldPath parses a field of the Value, with or without the leading `$.`.
+func fieldPath(field string) (jsonpath.Path, error) {
+	if !jsonpath.IsPath(field) {
+		field = jsonpath.ROOT + "." + field
+	}
+	path, err := jsonpath.Parse(field)
+	if err == nil && len(path) == 0 {
+		err = errors.New("field must not be the root")
+	}
+	return path, err
+}
+
+func renameOp(from, to jsonpath.Path) transformOp {
+	return func(value jsonObj) error {
+		v, ok := from.Get(value)
+		if !ok {
+			return nil
+		}
+		from.Delete(value)
+		return to.Set(value, v)
+	}
+}
+
+func dropOp(paths []jsonpath.Path) transformOp {
+	return func(value jsonObj) error {
+		for _, path := range paths {
+			path.Delete(value)
+		}
+		return nil
+	}
+}
+
+// eachField sets each field to what fn returns, when fn asks for it.
+func eachField(paths []jsonpath.Path, fn func(v interface{}, ok bool) (interface{}, bool, error)) transformOp {
+	return func(value jsonObj) error {
+		for _, path := range paths {
+			v, ok := path.Get(value)
+			newV, set, err := fn(v, ok)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if !set {
+				continue
+			}
+			if err := path.Set(value, newV); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// eachExisting replaces each field that is set and not null by what fn
+// returns. Missing fields are left alone.
+func eachExisting(paths []jsonpath.Path, fn func(v interface{}) (interface{}, error)) transformOp {
+	return eachField(paths, func(v interface{}, ok bool) (interface{}, bool, error) {
+		if !ok || v == nil {
+			return nil, false, nil
+		}
+		newV, err := fn(v)
+		return newV, err == nil, err
+	})
+}
+
+func flattenOp(paths []jsonpath.Path, sep string) transformOp {
+	return func(value jsonObj) error {
+		// 필드가 없으면 Value 전체를 펼친다.
+		if len(paths) == 0 {
+			flat := make(jsonObj)
+			flatten("", value, sep, flat)
+			for k := range value {
+				delete(value, k)
+			}
+			for k, v := range flat {
+				value[k] = v
+			}
+			return nil
+		}
+		// 필드의 오브젝트를 필드 이름을 앞에 붙여 상위 오브젝트로 펼친다.
+		for _, path := range paths {
+			v, ok := path.Get(value)
+			obj, isObj := v.(jsonObj)
+			if !ok || !isObj || path.Key() == "" {
+				continue
+			}
+			parent := value
+			if len(path) > 1 {
+				p, _ := path[:len(path)-1].Get(value)
+				parent = p.(jsonObj)
+			}
+			delete(parent, path.Key())
+			flatten(path.Key(), obj, sep, parent)
+		}
+		return nil
+	}
+}
+
+func flatten(prefix string, obj jsonObj, sep string, out jsonObj) {
+	for k, v := range obj {
+		key := k
+		if prefix != "" {
+			key = prefix + sep + k
+		}
+		if nested, ok := v.(jsonObj); ok && len(nested) > 0 {
+			flatten(key, nested, sep, out)
+			continue
+		}
+		out[key] = v
+	}
+}
+
+// caster returns the function converting a value to the type.
+func caster(typ, unit string) (func(v interface{}) (interface{}, error), error) {
+	switch typ {
+	case CAST_STRING:
+		return func(v interface{}) (interface{}, error) { return stringify(v), nil }, nil
+	case CAST_NUMBER:
+		return toNumber, nil
+	case CAST_INT:
+		return func(v interface{}) (interface{}, error) {
+			n, err := toNumber(v)
+			if err != nil {
+				return nil, err
+			}
+			return float64(int64(n.(float64))), nil
+		}, nil
+	case CAST_BOOL:
+		return func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return strconv.ParseBool(stringify(v))
+		}, nil
+	case CAST_RFC3339:
+		scale, ok := epochUnits[unit]
+		if !ok {
+			return nil, fmt.Errorf("invalid unit %q. Must be one of: s, ms, us, ns", unit)
+		}
+		return func(v interface{}) (interface{}, error) {
+			n, err := toNumber(v)
+			if err != nil {
+				return nil, err
+			}
+			epoch := n.(float64)
+			// 정수 epoch 는 부동소수점 오차 없이 변환한다.
+			nanos := int64(epoch * float64(scale))
+			if epoch == math.Trunc(epoch) {
+				nanos = int64(epoch) * int64(scale)
+			}
+			return time.Unix(0, nanos).UTC().Format(time.RFC3339Nano), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid type %q. Must be one of: %s, %s, %s, %s, %s", typ, CAST_STRING, CAST_NUMBER, CAST_INT, CAST_BOOL, CAST_RFC3339)
+}
+
+// epoch 단위 별 나노초
+var epochUnits = map[string]time.Duration{
+	"":   time.Second,
+	"s":  time.Second,
+	"ms": time.Millisecond,
+	"us": time.Microsecond,
+	"ns": time.Nanosecond,
+}
+
+func toNumber(v interface{}) (interface{}, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case bool:
+		if n {
+			return float64(1), nil
+		}
+		return float64(0), nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(n), 64)
+	}
+	return nil, fmt.Errorf("cannot cast %T to a number", v)
+}
+
+// mask replaces the characters of s but the first keepFirst and the last
+// keepLast ones.
+func mask(s string, keepFirst, keepLast int, maskChar string) string {
+	runes := []rune(s)
+	if keepFirst+keepLast >= len(runes) {
+		return strings.Repeat(maskChar, len(runes))
+	}
+	masked := strings.Repeat(maskChar, len(runes)-keepFirst-keepLast)
+	return string(runes[:keepFirst]) + masked + string(runes[len(runes)-keepLast:])
+}
@@ -0,0 +1,117 @@
+package processors_test
+
+import (
+	"context"
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/processors"
+	"reflect"
+	"testing"
+)
+
+func TestTransform_Process(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		ops   []processors.TransformOpCfg
+		value jsonObj
+		want  jsonObj
+	}{
+		{
+			desc:  "rename",
+			ops:   []processors.TransformOpCfg{{Op: processors.TRANSFORM_RENAME, Field: "user_name", To: "user.name"}},
+			value: jsonObj{"user_name": "jane"},
+			want:  jsonObj{"user": jsonObj{"name": "jane"}},
+		},
+		{
+			desc:  "drop",
+			ops:   []processors.TransformOpCfg{{Op: processors.TRANSFORM_DROP, Fields: []string{"password", "$.user.token", "missing"}}},
+			value: jsonObj{"id": "1", "password": "secret", "user": jsonObj{"token": "t"}},
+			want:  jsonObj{"id": "1", "user": jsonObj{}},
+		},
+		{
+			desc:  "default",
+			ops:   []processors.TransformOpCfg{{Op: processors.TRANSFORM_DEFAULT, Fields: []string{"currency", "country"}, Value: "KRW"}},
+			value: jsonObj{"currency": "USD", "country": nil},
+			want:  jsonObj{"currency": "USD", "country": "KRW"},
+		},
+		{
+			desc: "cast",
+			ops: []processors.TransformOpCfg{
+				{Op: processors.TRANSFORM_CAST, Field: "price", Type: processors.CAST_NUMBER},
+				{Op: processors.TRANSFORM_CAST, Field: "quantity", Type: processors.CAST_INT},
+				{Op: processors.TRANSFORM_CAST, Field: "id", Type: processors.CAST_STRING},
+				{Op: processors.TRANSFORM_CAST, Field: "active", Type: processors.CAST_BOOL},
+				{Op: processors.TRANSFORM_CAST, Field: "created_at", Type: processors.CAST_RFC3339, Unit: "ms"},
+			},
+			value: jsonObj{"price": "9.99", "quantity": "3.7", "id": float64(12), "active": "true", "created_at": float64(1656633600123)},
+			want:  jsonObj{"price": 9.99, "quantity": float64(3), "id": "12", "active": true, "created_at": "2022-07-01T00:00:00.123Z"},
+		},
+		{
+			desc: "hash and mask",
+			ops: []processors.TransformOpCfg{
+				{Op: processors.TRANSFORM_HASH, Field: "email"},
+				{Op: processors.TRANSFORM_MASK, Field: "phone", KeepLast: 4},
+				{Op: processors.TRANSFORM_MASK, Field: "pin"},
+			},
+			value: jsonObj{"email": "jane@example.com", "phone": "010-1234-5678", "pin": "1234"},
+			want: jsonObj{
+				"email": "8c87b489ce35cf2e2f39f80e282cb2e804932a56a213983eeeb428407d43b52d",
+				"phone": "*********5678",
+				"pin":   "****",
+			},
+		},
+		{
+			desc:  "flatten",
+			ops:   []processors.TransformOpCfg{{Op: processors.TRANSFORM_FLATTEN}},
+			value: jsonObj{"id": "1", "user": jsonObj{"name": "jane", "address": jsonObj{"city": "seoul"}}},
+			want:  jsonObj{"id": "1", "user.name": "jane", "user.address.city": "seoul"},
+		},
+		{
+			desc:  "flatten field",
+			ops:   []processors.TransformOpCfg{{Op: processors.TRANSFORM_FLATTEN, Field: "user.address", Separator: "_"}},
+			value: jsonObj{"user": jsonObj{"address": jsonObj{"city": "seoul"}}},
+			want:  jsonObj{"user": jsonObj{"address_city": "seoul"}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			tr, err := processors.NewTransform(processors.TransformCfg{Operations: tC.ops})
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := tr.Process(context.Background(), &payloads.Event{Value: tC.value})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.(*payloads.Event).Value; !reflect.DeepEqual(got, tC.want) {
+				t.Errorf("expected %v, got %v", tC.want, got)
+			}
+		})
+	}
+}
+
+func TestTransform_CastError(t *testing.T) {
+	tr, err := processors.NewTransform(processors.TransformCfg{Operations: []processors.TransformOpCfg{
+		{Op: processors.TRANSFORM_CAST, Field: "price", Type: processors.CAST_NUMBER},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.Process(context.Background(), &payloads.Event{Value: jsonObj{"price": "free"}}); err == nil {
+		t.Errorf("expected an error casting an invalid number")
+	}
+}
+
+func TestNewTransform_InvalidConfig(t *testing.T) {
+	for _, op := range []processors.TransformOpCfg{
+		{Op: "upper", Field: "name"},
+		{Op: processors.TRANSFORM_DROP},
+		{Op: processors.TRANSFORM_RENAME, Field: "a"},
+		{Op: processors.TRANSFORM_CAST, Field: "a", Type: "date"},
+		{Op: processors.TRANSFORM_CAST, Field: "a", Type: processors.CAST_RFC3339, Unit: "h"},
+		{Op: processors.TRANSFORM_MASK, Field: "a", KeepLast: -1},
+	} {
+		if _, err := processors.NewTransform(processors.TransformCfg{Operations: []processors.TransformOpCfg{op}}); err == nil {
+			t.Errorf("expected an error for %+v", op)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package casters
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/streadway/amqp"
+)
+
+// JSON_CASTER is used for queues that have no caster of their own.
+const JSON_CASTER = "json" + CASTER_SUFFIX
+
+func init() {
+	Register(JSON_CASTER, NewJSONCaster)
+}
+
+func NewJSONCaster() Caster {
+	return CasterFunc(CastJSON)
+}
+
+// CastJSON casts a message with a json object body without assuming any
+// schema. The message body is kept as raw bytes next to the decoded value.
+func CastJSON(meta jsonObj, message amqp.Delivery) (jsonObj, error) {
+	var record = make(jsonObj)
+
+	//Queue 이름과 같은 메타 정보
+	for k, v := range meta {
+		record[k] = v
+	}
+
+	var valObj jsonObj
+	err := json.Unmarshal(message.Body, &valObj)
+	if err != nil {
+		return nil, fmt.Errorf("error in casting value object: %w", err)
+	}
+
+	if len(message.Headers) > 0 {
+		headers := make(map[string]string, len(message.Headers))
+		for k, v := range message.Headers {
+			headers[k] = fmt.Sprint(v)
+		}
+		record["headers"] = headers
+	}
+	record["exchange"] = message.Exchange
+	record["routing_key"] = message.RoutingKey
+	record["key"] = message.MessageId
+	record["value"] = valObj
+	record["raw"] = message.Body
+	record["timestamp"] = message.Timestamp
+	return record, nil
+}
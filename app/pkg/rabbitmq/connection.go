@@ -0,0 +1,27 @@
+package rabbitmq
+
+import (
+	"event-data-pipeline/pkg/logger"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// Dial connects to the RabbitMQ host, retrying every second until it
+// succeeds or maxRetries is exceeded. A negative maxRetries retries forever.
+func Dial(host string, maxRetries int) (*amqp.Connection, error) {
+	retry := 0
+	for {
+		conn, err := amqp.Dial(host)
+		if conn != nil {
+			logger.Infof("rabbitmq connection made")
+			return conn, nil
+		}
+		retry++
+		if maxRetries >= 0 && retry > maxRetries {
+			return nil, err
+		}
+		time.Sleep(1 * time.Second)
+		logger.Infof("retry to connect to rabbitmq")
+	}
+}
@@ -16,7 +16,10 @@ var _ Consumer = new(RabbitMQConsumer)
 
 type Consumer interface {
 	CreateConsumer() error
+	ExchangeDeclare() error
 	QueueDeclare() error
+	QueueBind() error
+	Setup() error
 	Read(ctx context.Context) error
 	Connect() error
 	CreateChannel() error
@@ -54,6 +57,10 @@ func (rc *RabbitMQConsumer) Read(ctx context.Context) error {
 		if err != nil {
 			return nil, err
 		}
+		// 싱크에 쓰기가 완료되면 메시지를 ack 할 수 있도록 전달 핸들을 추가
+		if rc.manualAck() {
+			record[payloads.ACK_KEY] = rc.newAck(msg)
+		}
 		return record, nil
 	}
 
@@ -74,6 +81,11 @@ func (rc *RabbitMQConsumer) Read(ctx context.Context) error {
 			record, err := cast(msg)
 			if err != nil {
 				logger.Errorf(err.Error())
+				if rc.manualAck() {
+					// 변환할 수 없는 메시지는 다시 받아도 실패하므로 큐에 다시 넣지 않는다.
+					msg.Nack(false, false)
+					continue
+				}
 			}
 			data, _ := json.Marshal(record)
 			logger.Debugf("rabbitmq message :%s", string(data))
@@ -85,6 +97,30 @@ func (rc *RabbitMQConsumer) Read(ctx context.Context) error {
 	}
 }
 
+// manualAck reports whether deliveries are acknowledged after they have been
+// written by the storages rather than on delivery.
+func (rc *RabbitMQConsumer) manualAck() bool {
+	return rc.config.AckMode == ACK_MODE_MANUAL
+}
+
+// newAck returns the delivery handle of a message. The message is acked once
+// its payload has been handled, and nacked when handling fails.
+func (rc *RabbitMQConsumer) newAck(msg amqp.Delivery) *payloads.Ack {
+	return payloads.NewAck(
+		func() {
+			if err := msg.Ack(false); err != nil {
+				logger.Errorf("[Queue: %s][DeliveryTag: %d] error in acking: %v", rc.config.QueueName, msg.DeliveryTag, err)
+			}
+		},
+		func(err error) {
+			logger.Errorf("[Queue: %s][DeliveryTag: %d] not processed, requeue: %v: %v", rc.config.QueueName, msg.DeliveryTag, rc.config.Requeue, err)
+			if err := msg.Nack(false, rc.config.Requeue); err != nil {
+				logger.Errorf("[Queue: %s][DeliveryTag: %d] error in nacking: %v", rc.config.QueueName, msg.DeliveryTag, err)
+			}
+		},
+	)
+}
+
 func NewRabbitMQConsumer(config jsonObj) *RabbitMQConsumer {
 	//context, stream, errch 추출
 	ctx, stream, errch := extractPipeParams(config)
@@ -106,8 +142,21 @@ func NewRabbitMQConsumer(config jsonObj) *RabbitMQConsumer {
 		logger.Panicf("error in loading rabbitmq configuration: %v", err)
 		return nil
 	}
+	switch cfg.AckMode {
+	case "":
+		cfg.AckMode = ACK_MODE_AUTO
+	case ACK_MODE_AUTO, ACK_MODE_MANUAL:
+	default:
+		logger.Panicf("invalid ack_mode %s. Must be one of: %s, %s", cfg.AckMode, ACK_MODE_AUTO, ACK_MODE_MANUAL)
+		return nil
+	}
+	// 큐 전용 caster 가 없으면 스키마 없이 json 으로 변환
 	casterName := cfg.QueueName + casters.CASTER_SUFFIX
 	castFunc, err := casters.CreateCaster(casterName)
+	if err != nil {
+		logger.Infof("no %s registered, casting messages as json", casterName)
+		castFunc, err = casters.CreateCaster(casters.JSON_CASTER)
+	}
 	if err != nil {
 		logger.Panicf("error in loading caster function: %v", err)
 		return nil
@@ -123,6 +172,8 @@ func NewRabbitMQConsumer(config jsonObj) *RabbitMQConsumer {
 	return c
 }
 
+// CreateConsumer connects to the broker and sets up the exchange, queue,
+// bindings and prefetch on a new channel.
 func (c *RabbitMQConsumer) CreateConsumer() error {
 
 	// best practice is to reuse connections and channels
@@ -134,28 +185,51 @@ func (c *RabbitMQConsumer) CreateConsumer() error {
 	if err != nil {
 		return err
 	}
+	err = c.Setup()
+	if err != nil {
+		return err
+	}
 	logger.Debugf("Check in consumer creation: %v", c.ch)
 
 	return nil
 }
 
-func (c *RabbitMQConsumer) Connect() error {
-	c.ConnMaxRetries = -1
-	retry := 0
-	for {
-		conn, err := amqp.Dial(c.config.Host)
-		if conn != nil {
-			logger.Infof("rabbitmq connection made")
-			c.conn = conn
-			break
-		}
-		retry++
-		if c.ConnMaxRetries >= 0 && retry > c.ConnMaxRetries {
+// Setup declares the exchange and the queue, binds them and sets the
+// prefetch count of the channel. Qos only applies to the channel it is set
+// on, so it has to be redone on every new channel as well.
+func (c *RabbitMQConsumer) Setup() error {
+	err := c.ExchangeDeclare()
+	if err != nil {
+		return err
+	}
+	err = c.QueueDeclare()
+	if err != nil {
+		return err
+	}
+	err = c.QueueBind()
+	if err != nil {
+		return err
+	}
+	if c.config.Prefetch > 0 {
+		err = c.ch.Qos(
+			c.config.Prefetch, // prefetch count
+			0,                 // prefetch size
+			false,             // global
+		)
+		if err != nil {
 			return err
 		}
-		time.Sleep(1 * time.Second)
-		logger.Infof("retry to connect to rabbitmq")
 	}
+	return nil
+}
+
+func (c *RabbitMQConsumer) Connect() error {
+	c.ConnMaxRetries = -1
+	conn, err := Dial(c.config.Host, c.ConnMaxRetries)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
 	//Listen to NotifyClose
 	go func() {
 		<-c.conn.NotifyClose(make(chan *amqp.Error))
@@ -190,13 +264,12 @@ func (c *RabbitMQConsumer) Delete() error {
 
 	return nil
 }
+
+// ReConnect creates the consumer again, including the exchange, queue,
+// bindings and prefetch, and starts consuming on the new channel.
 func (c *RabbitMQConsumer) ReConnect() error {
 	logger.Debugf("Reconnecting")
-	err := c.Connect()
-	if err != nil {
-		return err
-	}
-	err = c.CreateChannel()
+	err := c.CreateConsumer()
 	if err != nil {
 		return err
 	}
@@ -209,14 +282,32 @@ func (c *RabbitMQConsumer) ReConnect() error {
 
 }
 
+// ExchangeDeclare declares the exchange when its name and type are
+// configured. Without an exchange the queue is read through the default
+// exchange.
+func (c *RabbitMQConsumer) ExchangeDeclare() error {
+	if c.config.ExchangeName == "" || c.config.ExchangeType == "" {
+		return nil
+	}
+	return c.ch.ExchangeDeclare(
+		c.config.ExchangeName, // name
+		c.config.ExchangeType, // type
+		true,                  // durable
+		false,                 // auto-deleted
+		false,                 // internal
+		false,                 // no-wait
+		nil,                   // arguments
+	)
+}
+
 func (c *RabbitMQConsumer) QueueDeclare() error {
 	q, err := c.ch.QueueDeclare(
-		c.config.QueueName, // name
-		true,               // durable
-		false,              // delete when unused
-		false,              // exclusive
-		false,              // no-wait
-		nil,                // arguments
+		c.config.QueueName,            // name
+		true,                          // durable
+		false,                         // delete when unused
+		false,                         // exclusive
+		false,                         // no-wait
+		QueueArgs(c.config.QueueArgs), // arguments
 	)
 	c.q = q
 	if err != nil {
@@ -225,11 +316,72 @@ func (c *RabbitMQConsumer) QueueDeclare() error {
 	return nil
 }
 
+// QueueBind binds the queue to the exchange with every configured routing
+// key. A queue without routing keys is bound with an empty key, which is all
+// fanout exchanges need.
+func (c *RabbitMQConsumer) QueueBind() error {
+	if c.config.ExchangeName == "" {
+		return nil
+	}
+	for _, key := range c.bindingKeys() {
+		err := c.ch.QueueBind(
+			c.config.QueueName,    // queue name
+			key,                   // routing key
+			c.config.ExchangeName, // exchange
+			false,                 // no-wait
+			nil,                   // arguments
+		)
+		if err != nil {
+			return err
+		}
+		logger.Infof("queue %s bound to exchange %s with routing key %q", c.config.QueueName, c.config.ExchangeName, key)
+	}
+	return nil
+}
+
+// bindingKeys returns routing_key and routing_keys without duplicates.
+func (c *RabbitMQConsumer) bindingKeys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, key := range append([]string{c.config.RoutingKey}, c.config.RoutingKeys...) {
+		if seen[key] || (key == "" && len(c.config.RoutingKeys) > 0) {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// QueueArgs converts queue arguments read from the configuration to an
+// amqp.Table. Numbers are decoded from json as float64 while RabbitMQ expects
+// integer arguments such as x-message-ttl, so whole numbers become int64.
+func QueueArgs(args jsonObj) amqp.Table {
+	if len(args) == 0 {
+		return nil
+	}
+	table := make(amqp.Table, len(args))
+	for k, v := range args {
+		switch n := v.(type) {
+		case float64:
+			if n == float64(int64(n)) {
+				table[k] = int64(n)
+				continue
+			}
+		case int:
+			table[k] = int64(n)
+			continue
+		}
+		table[k] = v
+	}
+	return table
+}
+
 func (c *RabbitMQConsumer) InitDeliveryChannel() error {
 	msg, err := c.ch.Consume(
 		c.config.QueueName, // queue
 		"edp-consumer",     // consumer
-		true,               // auto-ack
+		!c.manualAck(),     // auto-ack
 		false,              // exclusive
 		false,              // no-local
 		false,              // no-wait
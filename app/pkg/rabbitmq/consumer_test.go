@@ -0,0 +1,37 @@
+package rabbitmq_test
+
+import (
+	"event-data-pipeline/pkg/rabbitmq"
+	"reflect"
+	"testing"
+
+	"github.com/streadway/amqp"
+)
+
+func TestQueueArgs(t *testing.T) {
+	testCases := []struct {
+		desc string
+		args map[string]interface{}
+		want amqp.Table
+	}{
+		{desc: "no arguments", args: nil, want: nil},
+		{
+			desc: "json numbers to integers",
+			args: map[string]interface{}{"x-message-ttl": float64(60000), "x-max-length": 1000},
+			want: amqp.Table{"x-message-ttl": int64(60000), "x-max-length": int64(1000)},
+		},
+		{
+			desc: "other arguments unchanged",
+			args: map[string]interface{}{"x-dead-letter-exchange": "dlx", "x-ratio": 0.5},
+			want: amqp.Table{"x-dead-letter-exchange": "dlx", "x-ratio": 0.5},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			got := rabbitmq.QueueArgs(tC.args)
+			if !reflect.DeepEqual(got, tC.want) {
+				t.Errorf("expected %v, got %v", tC.want, got)
+			}
+		})
+	}
+}
@@ -1,4 +1,278 @@
 package rabbitmq
 
+import (
+	"encoding/json"
+	"errors"
+	"event-data-pipeline/pkg/logger"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+var _ Producer = new(RabbitMQProducer)
+
+type Producer interface {
+	CreateProducer() error
+	Connect() error
+	CreateChannel() error
+	ExchangeDeclare() error
+	ReConnect() error
+	Publish(routingKey, messageID string, body []byte) error
+	Delete() error
+}
+
+const (
+	DEFAULT_CONFIRM_TIMEOUT_MS = 5000
+	DEFAULT_PUBLISH_RETRIES    = 3
+)
+
+// Connection is the part of *amqp.Connection the producer uses.
+type Connection interface {
+	Channel() (Channel, error)
+	IsClosed() bool
+	Close() error
+}
+
+// Channel is the part of *amqp.Channel the producer uses.
+type Channel interface {
+	Confirm(noWait bool) error
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Close() error
+}
+
+// amqpConnection adapts *amqp.Connection to Connection.
+type amqpConnection struct {
+	*amqp.Connection
+}
+
+func (c amqpConnection) Channel() (Channel, error) {
+	ch, err := c.Connection.Channel()
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// DialConnection connects to the RabbitMQ host like Dial.
+func DialConnection(host string, maxRetries int) (Connection, error) {
+	conn, err := Dial(host, maxRetries)
+	if err != nil {
+		return nil, err
+	}
+	return amqpConnection{conn}, nil
+}
+
+// RabbitMQProducer publishes messages to an exchange with publisher confirms.
+// A message is only reported as published once the broker has confirmed it;
+// on a closed connection or channel, or when the confirm times out, the
+// producer reconnects and publishes the message again.
 type RabbitMQProducer struct {
+	config         *RabbitMQProducerConfig
+	conn           Connection
+	ConnMaxRetries int
+	// Dialer connects to the broker, DialConnection by default
+	Dialer         func(host string, maxRetries int) (Connection, error)
+	ch             Channel
+	confirms       chan amqp.Confirmation
+	confirmTimeout time.Duration
+	maxRetries     int
+	// 현재 채널에서 마지막으로 발행한 메시지의 delivery tag
+	deliveryTag uint64
+
+	// 채널은 고루틴에 안전하지 않고, confirm 은 발행 순서대로 도착하므로 한번에 하나씩 발행
+	mu sync.Mutex
+}
+
+func NewRabbitMQProducer(config jsonObj) *RabbitMQProducer {
+	//producerCfg
+	rbbtmqPrdcrCfg, ok := config["producerCfg"].(jsonObj)
+	if !ok {
+		logger.Panicf("no producer options provided")
+	}
+	var cfg RabbitMQProducerConfig
+	cfgData, err := json.Marshal(rbbtmqPrdcrCfg)
+	if err != nil {
+		logger.Panicf("error in mashalling rabbitmq configuration: %v", err)
+		return nil
+	}
+
+	err = json.Unmarshal(cfgData, &cfg)
+	if err != nil {
+		logger.Panicf("error in loading rabbitmq configuration: %v", err)
+		return nil
+	}
+
+	confirmTimeout := DEFAULT_CONFIRM_TIMEOUT_MS * time.Millisecond
+	if cfg.ConfirmTimeoutMs > 0 {
+		confirmTimeout = time.Duration(cfg.ConfirmTimeoutMs) * time.Millisecond
+	}
+	maxRetries := DEFAULT_PUBLISH_RETRIES
+	if cfg.MaxRetries > 0 {
+		maxRetries = cfg.MaxRetries
+	}
+
+	return &RabbitMQProducer{
+		config:         &cfg,
+		ConnMaxRetries: -1,
+		Dialer:         DialConnection,
+		confirmTimeout: confirmTimeout,
+		maxRetries:     maxRetries,
+	}
+}
+
+func (p *RabbitMQProducer) CreateProducer() error {
+	err := p.Connect()
+	if err != nil {
+		return err
+	}
+	err = p.CreateChannel()
+	if err != nil {
+		return err
+	}
+	err = p.ExchangeDeclare()
+	if err != nil {
+		return err
+	}
+	logger.Debugf("Check in producer creation: %v", p.ch)
+	return nil
+}
+
+func (p *RabbitMQProducer) Connect() error {
+	conn, err := p.Dialer(p.config.Host, p.ConnMaxRetries)
+	if err != nil {
+		return err
+	}
+	p.conn = conn
+	return nil
+}
+
+// CreateChannel opens a channel in confirm mode.
+func (p *RabbitMQProducer) CreateChannel() error {
+	ch, err := p.conn.Channel()
+	if err != nil {
+		return err
+	}
+	err = ch.Confirm(false)
+	if err != nil {
+		return err
+	}
+	p.ch = ch
+	p.confirms = ch.NotifyPublish(make(chan amqp.Confirmation, 1))
+	// confirm 모드의 delivery tag 는 채널마다 1 부터 시작
+	p.deliveryTag = 0
+	return nil
+}
+
+// ExchangeDeclare declares the exchange when its type is configured. The
+// default exchange ("") and exchanges managed elsewhere are not declared.
+func (p *RabbitMQProducer) ExchangeDeclare() error {
+	if p.config.ExchangeName == "" || p.config.ExchangeType == "" {
+		return nil
+	}
+	return p.ch.ExchangeDeclare(
+		p.config.ExchangeName, // name
+		p.config.ExchangeType, // type
+		true,                  // durable
+		false,                 // auto-deleted
+		false,                 // internal
+		false,                 // no-wait
+		nil,                   // arguments
+	)
+}
+
+func (p *RabbitMQProducer) ReConnect() error {
+	logger.Debugf("Reconnecting")
+	p.Delete()
+	return p.CreateProducer()
+}
+
+// Publish publishes a persistent message to the configured exchange and
+// waits for the broker to confirm it. An empty routing key falls back to the
+// configured one.
+func (p *RabbitMQProducer) Publish(routingKey, messageID string, body []byte) error {
+	if routingKey == "" {
+		routingKey = p.config.RoutingKey
+	}
+	msg := amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    messageID,
+		Timestamp:    time.Now(),
+		Body:         body,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+	for retry := 0; retry <= p.maxRetries; retry++ {
+		if retry > 0 || p.ch == nil || p.conn == nil || p.conn.IsClosed() {
+			if err := p.ReConnect(); err != nil {
+				return err
+			}
+		}
+		err = p.publish(routingKey, msg)
+		if err == nil {
+			return nil
+		}
+		logger.Errorf("error in publishing to %s/%s: %v", p.config.ExchangeName, routingKey, err)
+	}
+	return err
+}
+
+func (p *RabbitMQProducer) publish(routingKey string, msg amqp.Publishing) error {
+	err := p.ch.Publish(
+		p.config.ExchangeName, // exchange
+		routingKey,            // routing key
+		false,                 // mandatory
+		false,                 // immediate
+		msg,
+	)
+	if err != nil {
+		return err
+	}
+	p.deliveryTag++
+
+	timeout := time.After(p.confirmTimeout)
+	for {
+		select {
+		case confirm, ok := <-p.confirms:
+			if !ok {
+				p.Delete()
+				return errors.New("rabbitmq channel closed before confirm")
+			}
+			// 이전 메시지에 대한 confirm 은 건너뛴다.
+			if confirm.DeliveryTag < p.deliveryTag {
+				logger.Debugf("skipping confirm of earlier delivery %d", confirm.DeliveryTag)
+				continue
+			}
+			if !confirm.Ack {
+				return fmt.Errorf("rabbitmq nacked delivery %d", confirm.DeliveryTag)
+			}
+			return nil
+		case <-timeout:
+			// 늦게 도착한 confirm 이 다음 메시지와 섞이지 않도록 연결을 끊고 다음 발행 때 다시 연결한다.
+			p.Delete()
+			return fmt.Errorf("rabbitmq confirm timeout for delivery %d", p.deliveryTag)
+		}
+	}
+}
+
+func (p *RabbitMQProducer) Delete() error {
+	logger.Debugf("deleting rabbit mq producer connection: %s and channel: %s", p.conn, p.ch)
+	if p.ch != nil {
+		p.ch.Close()
+		p.ch = nil
+	}
+	if p.conn != nil && !p.conn.IsClosed() {
+		err := p.conn.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,214 @@
+package rabbitmq_test
+
+import (
+	"errors"
+	"event-data-pipeline/pkg/rabbitmq"
+	"sync"
+	"testing"
+
+	"github.com/streadway/amqp"
+)
+
+// fakeBroker answers each published message with the next scripted action:
+// ack, nack, none (no confirm), stale (a confirm of an earlier delivery
+// before the ack) or fail (the publish itself fails).
+type fakeBroker struct {
+	mu        sync.Mutex
+	actions   []string
+	dials     int
+	channels  []*fakeChannel
+	published []amqp.Publishing
+	keys      []string
+}
+
+func (b *fakeBroker) dial(host string, maxRetries int) (rabbitmq.Connection, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dials++
+	return &fakeConnection{broker: b}, nil
+}
+
+func (b *fakeBroker) next() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.actions) == 0 {
+		return "ack"
+	}
+	action := b.actions[0]
+	b.actions = b.actions[1:]
+	return action
+}
+
+type fakeConnection struct {
+	broker *fakeBroker
+	closed bool
+}
+
+func (c *fakeConnection) Channel() (rabbitmq.Channel, error) {
+	ch := &fakeChannel{broker: c.broker}
+	c.broker.mu.Lock()
+	c.broker.channels = append(c.broker.channels, ch)
+	c.broker.mu.Unlock()
+	return ch, nil
+}
+
+func (c *fakeConnection) IsClosed() bool { return c.closed }
+
+func (c *fakeConnection) Close() error {
+	c.closed = true
+	return nil
+}
+
+type fakeChannel struct {
+	broker   *fakeBroker
+	confirms chan amqp.Confirmation
+	tag      uint64
+	closed   bool
+}
+
+func (ch *fakeChannel) Confirm(noWait bool) error { return nil }
+
+func (ch *fakeChannel) NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation {
+	// 테스트에서는 confirm 을 바로 넣을 수 있도록 버퍼를 넉넉하게 둔다.
+	ch.confirms = make(chan amqp.Confirmation, 16)
+	return ch.confirms
+}
+
+func (ch *fakeChannel) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	return nil
+}
+
+func (ch *fakeChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	action := ch.broker.next()
+	if action == "fail" {
+		return errors.New("channel closed")
+	}
+	ch.broker.mu.Lock()
+	ch.broker.published = append(ch.broker.published, msg)
+	ch.broker.keys = append(ch.broker.keys, key)
+	ch.broker.mu.Unlock()
+
+	ch.tag++
+	switch action {
+	case "ack":
+		ch.confirms <- amqp.Confirmation{DeliveryTag: ch.tag, Ack: true}
+	case "nack":
+		ch.confirms <- amqp.Confirmation{DeliveryTag: ch.tag, Ack: false}
+	case "stale":
+		ch.confirms <- amqp.Confirmation{DeliveryTag: ch.tag - 1, Ack: false}
+		ch.confirms <- amqp.Confirmation{DeliveryTag: ch.tag, Ack: true}
+	}
+	return nil
+}
+
+func (ch *fakeChannel) Close() error {
+	ch.closed = true
+	return nil
+}
+
+func newProducer(t *testing.T, broker *fakeBroker) *rabbitmq.RabbitMQProducer {
+	t.Helper()
+	p := rabbitmq.NewRabbitMQProducer(map[string]interface{}{
+		"producerCfg": map[string]interface{}{
+			"exchange_name":      "events",
+			"routing_key":        "default",
+			"confirm_timeout_ms": 50,
+			"max_retries":        1,
+		},
+	})
+	p.Dialer = broker.dial
+	return p
+}
+
+func TestRabbitMQProducer_Publish(t *testing.T) {
+	broker := &fakeBroker{}
+	p := newProducer(t, broker)
+
+	if err := p.Publish("", "1", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Publish("purchases", "2", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if broker.dials != 1 {
+		t.Fatalf("expected 1 connection, got %d", broker.dials)
+	}
+	if len(broker.keys) != 2 || broker.keys[0] != "default" || broker.keys[1] != "purchases" {
+		t.Fatalf("expected routing keys [default purchases], got %v", broker.keys)
+	}
+	msg := broker.published[1]
+	if msg.MessageId != "2" || msg.DeliveryMode != amqp.Persistent {
+		t.Fatalf("expected a persistent message with id 2, got %+v", msg)
+	}
+}
+
+func TestRabbitMQProducer_Confirm(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		actions []string
+		wantErr bool
+		dials   int
+	}{
+		{desc: "ack", actions: []string{"ack"}, dials: 1},
+		{desc: "confirm of an earlier delivery is skipped", actions: []string{"ack", "stale"}, dials: 1},
+		{desc: "nack is retried on a new connection", actions: []string{"ack", "nack", "ack"}, dials: 2},
+		{desc: "failed publish is retried on a new connection", actions: []string{"ack", "fail", "ack"}, dials: 2},
+		{desc: "nack after retries", actions: []string{"ack", "nack", "nack"}, wantErr: true, dials: 2},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			broker := &fakeBroker{actions: tC.actions}
+			p := newProducer(t, broker)
+
+			// 첫 메시지는 항상 ack 되어 채널의 delivery tag 가 1 이후부터 시작한다.
+			if err := p.Publish("", "0", []byte(`{}`)); err != nil {
+				t.Fatal(err)
+			}
+			if len(tC.actions) > 1 {
+				err := p.Publish("", "1", []byte(`{}`))
+				if (err != nil) != tC.wantErr {
+					t.Fatalf("expected error %v, got %v", tC.wantErr, err)
+				}
+			}
+			if broker.dials != tC.dials {
+				t.Fatalf("expected %d connections, got %d", tC.dials, broker.dials)
+			}
+		})
+	}
+}
+
+func TestRabbitMQProducer_ConfirmTimeout(t *testing.T) {
+	broker := &fakeBroker{actions: []string{"none", "ack"}}
+	p := newProducer(t, broker)
+
+	// confirm 이 오지 않으면 채널을 닫고 새 연결에서 다시 발행한다.
+	if err := p.Publish("", "1", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if broker.dials != 2 || len(broker.channels) != 2 {
+		t.Fatalf("expected 2 connections and channels, got %d and %d", broker.dials, len(broker.channels))
+	}
+	if !broker.channels[0].closed {
+		t.Fatal("expected the channel that timed out to be closed")
+	}
+}
+
+func TestRabbitMQProducer_ConfirmTimeoutAfterRetries(t *testing.T) {
+	broker := &fakeBroker{actions: []string{"none", "none", "ack"}}
+	p := newProducer(t, broker)
+
+	if err := p.Publish("", "1", []byte(`{}`)); err == nil {
+		t.Fatal("expected a confirm timeout")
+	}
+	if !broker.channels[1].closed {
+		t.Fatal("expected the channel of the last attempt to be closed")
+	}
+
+	// 마지막 시도가 타임아웃이어도 다음 발행은 새 채널에서 한다.
+	if err := p.Publish("", "2", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if broker.dials != 3 || len(broker.channels) != 3 {
+		t.Fatalf("expected 3 connections and channels, got %d and %d", broker.dials, len(broker.channels))
+	}
+}
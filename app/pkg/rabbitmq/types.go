@@ -7,4 +7,30 @@ type RabbitMQConsumerConfig struct {
 	ExchangeType string `json:"exchange_type,omitempty"`
 	QueueName    string `json:"queue_name,omitempty"`
 	RoutingKey   string `json:"routing_key,omitempty"`
+	// routing_key 외에 추가로 큐를 바인딩할 라우팅 키
+	RoutingKeys []string `json:"routing_keys,omitempty"`
+	// x-message-ttl, x-max-length, x-dead-letter-exchange 등 큐 선언 인자
+	QueueArgs jsonObj `json:"queue_args,omitempty"`
+	// auto: 브로커가 전달과 동시에 ack, manual: 스토리지에 쓰기가 완료된 후 ack
+	AckMode string `json:"ack_mode,omitempty"`
+	// ack 되지 않은 채로 전달받을 수 있는 최대 메시지 수 (0 이면 제한 없음)
+	Prefetch int `json:"prefetch,omitempty"`
+	// manual 모드에서 처리에 실패한 메시지를 다시 큐에 넣을지 여부
+	Requeue bool `json:"requeue,omitempty"`
+}
+
+const (
+	ACK_MODE_AUTO   = "auto"
+	ACK_MODE_MANUAL = "manual"
+)
+
+type RabbitMQProducerConfig struct {
+	Host         string `json:"host,omitempty"`
+	ExchangeName string `json:"exchange_name,omitempty"`
+	ExchangeType string `json:"exchange_type,omitempty"`
+	RoutingKey   string `json:"routing_key,omitempty"`
+	// 브로커의 publisher confirm 을 기다리는 최대 시간
+	ConfirmTimeoutMs int `json:"confirm_timeout_ms,omitempty"`
+	// 발행 실패 시 재연결 후 다시 발행하는 횟수
+	MaxRetries int `json:"max_retries,omitempty"`
 }
@@ -2,7 +2,6 @@ package sources
 
 import (
 	"context"
-	"encoding/json"
 	"event-data-pipeline/pkg/kafka"
 	"event-data-pipeline/pkg/logger"
 	"event-data-pipeline/pkg/payloads"
@@ -23,17 +22,13 @@ func (kc *KafkaSource) Next(ctx context.Context) bool {
 		select {
 		// 스트림이 있을 때
 		case p := <-kc.Stream():
-			data, err := json.Marshal(p)
-			if err != nil {
-				logger.Errorf("failed to marshall the stream data")
+			record, ok := p.(map[string]interface{})
+			if !ok {
+				logger.Errorf("unexpected stream data type %T", p)
 				return false
 			}
-			var kfkPayload payloads.KafkaPayload
-			err = json.Unmarshal(data, &kfkPayload)
-			if err != nil {
-				logger.Errorf(err.Error())
-			}
-			kc.PutPaylod(&kfkPayload)
+			// 소스에 관계없이 같은 Event 타입으로 변환
+			kc.PutPaylod(payloads.NewEventFromRecord(payloads.SOURCE_KAFKA, record))
 			return true
 		// Shutdown
 		case <-ctx.Done():
@@ -10,6 +10,7 @@ import (
 	"event-data-pipeline/pkg/payloads"
 	"event-data-pipeline/pkg/ratelimit"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
@@ -21,20 +22,79 @@ import (
 
 // var _ pipelines.Sink = new(ElasticSearchClient)
 
+var _ io.Closer = new(ElasticSearchClient)
+
+// ElasticSearchClientConfig holds the settings of the elasticsearch storage
+// on top of the ones of the client.
+//
+// Buffered documents are written in bulk as soon as one of the flush
+// triggers is reached: flush_max_docs documents, flush_max_bytes bytes or
+// flush_interval_ms milliseconds. The buffer is also flushed when the
+// pipeline stops.
+//
+// Documents rejected with a retryable status are written again up to
+// max_retries times, -1 for no limit, with a backoff starting from delay
+// seconds. Documents failing for good fail their Ack, so the error policy of
+// the storage applies to them.
+//
+// op_type sets how documents are written, index by default, along with
+// routing, pipeline, doc_as_upsert and script (see es.Op). Event payloads
+// override op_type, routing and pipeline with the metadata of the same name,
+// e.g. op_type delete for tombstones.
+//
+// bootstrap lists the ILM policies and templates to create or update when the
+// storage starts (see es.BootstrapCfg). With rollover_alias or data_stream,
+// documents are written to the alias or the data stream, created at start
+// when missing, instead of the index of the payload. Data streams only take
+// create operations, so the default op_type becomes create. The bootstrap
+// requests are retried until bootstrap_timeout_ms, two minutes by default,
+// so the storage can start along with the cluster; max_retries only applies
+// to bulk writes.
+//
+// Closing the storage stops the retries of the bulk writes in flight, but the
+// final flush of the buffer keeps retrying, up to max_retries, for at most
+// close_timeout_ms, thirty seconds by default.
 type ElasticSearchClientConfig struct {
 	RateLimit  ratelimit.RateLimit `json:"rate_limit,omitempty"`
 	MaxRetries int                 `json:"max_retries,omitempty"`
 	Delay      int                 `json:"delay,omitempty"`
 	Worker     int                 `json:"worker,omitempty"`
+
+	FlushMaxDocs    int `json:"flush_max_docs,omitempty"`
+	FlushMaxBytes   int `json:"flush_max_bytes,omitempty"`
+	FlushIntervalMs int `json:"flush_interval_ms,omitempty"`
+
+	spes.Op
+
+	Bootstrap          spes.BootstrapCfg `json:"bootstrap,omitempty"`
+	BootstrapTimeoutMs int               `json:"bootstrap_timeout_ms,omitempty"`
+	CloseTimeoutMs     int               `json:"close_timeout_ms,omitempty"`
+	RolloverAlias      string            `json:"rollover_alias,omitempty"`
+	DataStream         string            `json:"data_stream,omitempty"`
 }
 type ElasticSearchClient struct {
 	client       *es.Client
 	DocumentType string
 	Refresh      bool
 
-	buf   bytes.Buffer
 	mu    sync.Mutex
-	count int
+	items []bulkItem
+	size  int
+
+	// 기본 벌크 액션
+	op spes.Op
+	// 페이로드 인덱스 대신 쓰는 롤오버 별칭이나 데이터 스트림
+	target string
+
+	// 플러시 조건
+	flushMaxDocs  int
+	flushMaxBytes int
+	flushInterval time.Duration
+
+	done      chan struct{}
+	closeOnce sync.Once
+	// 닫을 때 마지막 플러시의 재시도 제한 시간
+	closeTimeout time.Duration
 
 	workers *concur.WorkerPool
 	inCh    chan interface{}
@@ -71,6 +131,57 @@ func NewElasticSearchClient(config jsonObj) StorageProvider {
 		rateLimiter: ratelimit.NewRateLimiter(escConf.RateLimit),
 		maxRetries:  escConf.MaxRetries,
 		delay:       escConf.Delay,
+
+		flushMaxDocs:  escConf.FlushMaxDocs,
+		flushMaxBytes: escConf.FlushMaxBytes,
+		flushInterval: time.Duration(escConf.FlushIntervalMs) * time.Millisecond,
+		done:          make(chan struct{}),
+		closeTimeout:  time.Duration(escConf.CloseTimeoutMs) * time.Millisecond,
+
+		op: escConf.Op,
+	}
+	if ec.op.Type == "" {
+		ec.op.Type = spes.OP_INDEX
+	}
+
+	switch {
+	case escConf.RolloverAlias != "" && escConf.DataStream != "":
+		logger.Fatalf("error in creating elasticsearch client: rollover_alias and data_stream are mutually exclusive")
+	case escConf.RolloverAlias != "":
+		ec.target = escConf.RolloverAlias
+	case escConf.DataStream != "":
+		ec.target = escConf.DataStream
+		// 데이터 스트림은 create 만 받는다.
+		if ec.op.Type == spes.OP_INDEX {
+			ec.op.Type = spes.OP_CREATE
+		}
+		if ec.op.Type != spes.OP_CREATE {
+			logger.Fatalf("error in creating elasticsearch client: data streams do not support op_type %s", ec.op.Type)
+		}
+	}
+	if err := ec.op.Validate(); err != nil {
+		logger.Fatalf("error in creating elasticsearch client: %s", err)
+	}
+
+	// 템플릿, ILM 정책, 롤오버 별칭이나 데이터 스트림 준비
+	bootstrapTimeout := time.Duration(escConf.BootstrapTimeoutMs) * time.Millisecond
+	if bootstrapTimeout <= 0 {
+		bootstrapTimeout = spes.BOOTSTRAP_TIMEOUT_MS * time.Millisecond
+	}
+	if err := ec.bootstrap(escConf.Bootstrap, escConf.RolloverAlias, escConf.DataStream, bootstrapTimeout); err != nil {
+		logger.Fatalf("error in bootstrapping elasticsearch: %s", err)
+	}
+	if ec.flushMaxDocs <= 0 {
+		ec.flushMaxDocs = spes.RECORD_CNT_THRESHOLD
+	}
+	if ec.flushMaxBytes <= 0 {
+		ec.flushMaxBytes = spes.BYTES_THRESHOLD
+	}
+	if ec.flushInterval <= 0 {
+		ec.flushInterval = spes.TICKER_TIMEOUT_MS * time.Millisecond
+	}
+	if ec.closeTimeout <= 0 {
+		ec.closeTimeout = spes.CLOSE_TIMEOUT_MS * time.Millisecond
 	}
 
 	numWorkers := 1
@@ -81,12 +192,54 @@ func NewElasticSearchClient(config jsonObj) StorageProvider {
 	ec.workers = concur.NewWorkerPool("elasticsearch-workers", ec.inCh, numWorkers, ec.Write)
 	ec.workers.Start()
 
+	// 주기적인 플러시
+	go ec.flushPeriodically()
+
 	return ec
 }
 
 func (e *ElasticSearchClient) Drain(ctx context.Context, p payloads.Payload) error {
-	e.inCh <- p
-	return nil
+	select {
+	case e.inCh <- p:
+		return nil
+	case <-e.done:
+		return errors.New("elasticsearch storage is closed")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops the workers, once they are done with the documents they are
+// writing, and flushes the buffered documents. The acks of the flushed
+// documents are settled when Close returns.
+func (e *ElasticSearchClient) Close() error {
+	var err error
+	e.closeOnce.Do(func() {
+		close(e.done)
+		e.workers.Stop()
+
+		// 마지막 플러시는 done 이 닫혀도 재시도하되 closeTimeout 이 지나면 멈춘다.
+		stop := make(chan struct{})
+		timer := time.AfterFunc(e.closeTimeout, func() { close(stop) })
+		defer timer.Stop()
+		_, err = e.flush(spes.FLUSH_TRIGGER_CLOSE, stop)
+	})
+	return err
+}
+
+// flushPeriodically flushes the buffer every flush interval until the client
+// is closed, so documents of quiet sources are not kept in the buffer.
+func (e *ElasticSearchClient) flushPeriodically() {
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			e.flush(spes.FLUSH_TRIGGER_INTERVAL, e.done)
+		case <-e.done:
+			return
+		}
+	}
 }
 
 func (e *ElasticSearchClient) Write(payload interface{}) (int, error) {
@@ -95,131 +248,242 @@ func (e *ElasticSearchClient) Write(payload interface{}) (int, error) {
 	}
 	// 페이로드 가져오기
 	index, docID, data := payload.(payloads.Payload).Out()
-	if index == "" || docID == "" || len(data) == 0 {
-		return 0, errors.New("payload is nil")
+	if e.target != "" {
+		index = e.target
+	}
+	if index == "" || docID == "" {
+		err := errors.New("payload is nil")
+		payloads.Reject(payload, err)
+		return 0, err
+	}
+
+	// 이벤트 메타데이터로 문서별 벌크 액션 설정
+	op := e.op
+	if ev, ok := payload.(*payloads.Event); ok {
+		var err error
+		if op, err = op.WithMetadata(ev.Metadata); err != nil {
+			payloads.Reject(payload, err)
+			return 0, err
+		}
+	}
+
+	// 인덱스, documentID 메타정보와 데이터 줄을 하나의 문서로 보관
+	// 문서마다 인덱스를 지정하므로 하나의 벌크 요청이 여러 인덱스에 쓸 수 있다.
+	lines, err := op.Lines(index, docID, data)
+	if err != nil {
+		payloads.Reject(payload, err)
+		return 0, err
+	}
+
+	// 벌크 쓰기 결과에 따라 Acknowledge 하기 위해 전달 핸들도 보관
+	var ack *payloads.Ack
+	if a, ok := payload.(payloads.Acknowledger); ok {
+		ack = a.GetAck()
 	}
+
 	// 락 가져오기
 	e.mu.Lock()
-	defer e.mu.Unlock()
+	e.items = append(e.items, bulkItem{op: op.Type, lines: lines, ack: ack})
+	e.size += len(lines)
+
+	// 문서 수나 크기가 조건에 이르면 버퍼를 꺼내 락 밖에서 벌크 쓰기
+	var items []bulkItem
+	trigger := ""
+	switch {
+	case len(e.items) >= e.flushMaxDocs:
+		trigger = spes.FLUSH_TRIGGER_DOCS
+	case e.size >= e.flushMaxBytes:
+		trigger = spes.FLUSH_TRIGGER_BYTES
+	}
+	if trigger != "" {
+		items = e.take()
+	}
+	e.mu.Unlock()
 
-	// documentID 메타정보 오브젝트 생성
-	meta := []byte(fmt.Sprintf(`{ "index" : { "_id" : "%v" } }%s`, docID, "\n"))
+	return e.write(trigger, items, e.done)
+}
 
-	// bulk write 을 위한 개행
-	data = append(data, "\n"...)
+// flush writes the buffered documents in bulk, retrying until stop is
+// closed.
+func (e *ElasticSearchClient) flush(trigger string, stop <-chan struct{}) (int, error) {
+	e.mu.Lock()
+	items := e.take()
+	e.mu.Unlock()
+	return e.write(trigger, items, stop)
+}
 
-	// 카운터
-	e.count++
+// take returns the buffered documents and resets the buffer. The caller
+// must hold e.mu.
+func (e *ElasticSearchClient) take() []bulkItem {
+	items := e.items
+	e.items = nil
+	e.size = 0
+	return items
+}
 
-	// 메타, 데이타 오브젝트 사이즈 버퍼 할당
-	e.buf.Grow(len(meta) + len(data))
+// write writes documents taken from the buffer in bulk. It must be called
+// without holding e.mu, so other workers can buffer documents while the
+// request and its retries are in flight. Retries stop once stop is closed.
+func (e *ElasticSearchClient) write(trigger string, items []bulkItem, stop <-chan struct{}) (int, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	logger.Debugf("trigger bulk write on %s: %d", trigger, len(items))
+	esFlushTotal.WithLabelValues(trigger).Inc()
 
-	// 메타 정보 쓰기
-	e.buf.Write(meta)
+	// 벌크라이트, 문서별 전달 핸들은 bulkWrite 가 처리한다.
+	written, _, err := e.bulkWrite(items, stop)
+	return written, err
+}
 
-	// 데이터 정보 쓰기
-	e.buf.Write(data)
+// bulkItem is a buffered document: its action, its action and source lines
+// and the Ack to settle once it is written.
+type bulkItem struct {
+	op    string
+	lines []byte
+	ack   *payloads.Ack
+}
 
-	// 1000개 일때 벌크 쓰기
-	if e.count >= 1000 {
-		// 로컬 데이터 카피
-		buf := e.buf.Bytes()
-		// 벌크라이트
-		logger.Debugf("trigger bulk write : %d", e.count)
-		written, err := e.bulkWrite(index, buf)
-		if err != nil {
-			return 0, nil
+// bulkWrite writes the documents and settles their acks. It returns the
+// number of documents indexed and failed, and an error when some failed.
+//
+// Documents rejected with a retryable status (429, 5xx), or all of them when
+// the request itself fails that way, are written again with an exponential
+// backoff up to max_retries times. Other rejections, such as mapping errors,
+// are permanent: their Ack fails right away, which sends them to the failure
+// output of the storage, the dead letter storage with on_error dead_letter.
+// Documents still to retry when stop is closed fail as well.
+func (e *ElasticSearchClient) bulkWrite(items []bulkItem, stop <-chan struct{}) (int, int, error) {
+	indexed, failed := 0, 0
+	pending := items
+	var lastErr error
+	for attempt := 0; len(pending) > 0; attempt++ {
+		if attempt > 0 {
+			var err error
+			if e.maxRetries >= 0 && attempt > e.maxRetries {
+				err = fmt.Errorf("retry[%d] exceeded max retries[%d]: %w", attempt, e.maxRetries, lastErr)
+			} else {
+				backoff := spes.Backoff(e.delay, attempt)
+				logger.Infof("retrying[%d/%d] %d documents in %v", attempt, e.maxRetries, len(pending), backoff)
+				esRetryTotal.Add(float64(len(pending)))
+				err = e.wait(stop, backoff, attempt, lastErr)
+			}
+			if err != nil {
+				logger.Errorf("error in bulk writing : %s", err.Error())
+				failItems(pending, err)
+				failed += len(pending)
+				break
+			}
 		}
-		// 버퍼 초기화
-		e.buf.Reset()
-		// 카운트 초기화
-		e.count = 0
-		return written, nil
+
+		var n, f int
+		pending, n, f, lastErr = e.bulkAttempt(pending)
+		indexed += n
+		failed += f
+	}
+
+	//prometheus metrics counter
+	esWriteTotal.Add(float64(indexed))
+	esWriteErrorsTotal.Add(float64(failed))
+	if failed > 0 {
+		return indexed, failed, fmt.Errorf("%d of %d documents failed: %w", failed, len(items), lastErr)
+	}
+	return indexed, failed, nil
+}
+
+// wait sleeps for the backoff of a retry. It returns an error, without
+// waiting, once stop is closed.
+func (e *ElasticSearchClient) wait(stop <-chan struct{}, backoff time.Duration, attempt int, lastErr error) error {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-stop:
+		return fmt.Errorf("retry[%d] cancelled, elasticsearch storage is closed: %w", attempt, lastErr)
 	}
-	return 0, nil
 }
 
-func (e *ElasticSearchClient) bulkWrite(index string, data []byte) (int, error) {
+// bulkAttempt sends one bulk request. It returns the documents to retry, the
+// number of documents indexed and permanently failed, and the last error.
+func (e *ElasticSearchClient) bulkAttempt(items []bulkItem) ([]bulkItem, int, int, error) {
+	var body bytes.Buffer
+	for _, item := range items {
+		body.Write(item.lines)
+	}
+	logger.Debugf("writing data: %s", body.String())
 
-	logger.Debugf("writing data: %s", string(data))
-	retry := 0
-	reader := bytes.NewReader(data)
+	ctx := context.Background()
+	startWait := time.Now()
+	// rate limiting ...
+	e.rateLimiter.Wait(ctx)
+	logger.Debugf("rate limited for %f seconds", time.Since(startWait).Seconds())
 
-	for {
-		ctx := context.Background()
-		startWait := time.Now()
-		// rate limiting ...
-		e.rateLimiter.Wait(ctx)
-		logger.Debugf("rate limited for %f seconds", time.Since(startWait).Seconds())
-
-		// Observe write duration in seoncds and set histogram and guage metric
-
-		res, err := e.client.Bulk(reader,
-			e.client.Bulk.WithIndex(index),
-		)
-		// 에러가 발생했거나, 결과값이 없는 경우
-		if err != nil {
-			logger.Errorf("error in bulk writing : %s", err.Error())
-			retry++
-			if e.maxRetries >= 0 && retry > e.maxRetries {
-				err := fmt.Errorf("retry[%d] exceeded max retries[%d]", retry, e.maxRetries)
-				logger.Errorf("error in bulk writing : %s", err.Error())
-				return 0, err
-			}
-			time.Sleep(time.Duration(time.Duration(e.delay) * time.Second))
-			logger.Infof("retrying[%d/%d]", retry, e.maxRetries)
+	res, err := e.client.Bulk(&body)
+	// 요청 자체가 실패한 경우 모두 재시도
+	if err != nil {
+		logger.Errorf("error in bulk writing : %s", err.Error())
+		return items, 0, 0, err
+	}
+	defer res.Body.Close()
+
+	// 응답에 에러가 있는 경우
+	if res.IsError() {
+		var errRes spes.ErrorResponse
+		json.NewDecoder(res.Body).Decode(&errRes)
+		err := fmt.Errorf("bulk request failed: %s: %s: %s", res.Status(), errRes.Error.Type, errRes.Error.Reason)
+		logger.Errorf("Error: %s", err)
+		if spes.Retryable(res.StatusCode) {
+			return items, 0, 0, err
+		}
+		failItems(items, err)
+		return nil, 0, len(items), err
+	}
+
+	var blk spes.BulkResponse
+	if err := json.NewDecoder(res.Body).Decode(&blk); err != nil {
+		logger.Errorf("Failure to to parse response body: %s", err)
+		failItems(items, err)
+		return nil, 0, len(items), err
+	}
+
+	var retry []bulkItem
+	indexed, failed := 0, 0
+	for i, item := range items {
+		if i >= len(blk.Items) {
+			err = fmt.Errorf("no bulk result for document %d", i)
+			item.ack.Fail(err)
+			failed++
 			continue
 		}
-		defer res.Body.Close()
-
-		numIndexed := 0
-		numErrors := 0
-		//응답에 에러가 없는 경우
-		if !res.IsError() {
-			var blk *spes.BulkResponse
-			err := json.NewDecoder(res.Body).Decode(&blk)
-			if err != nil {
-				logger.Errorf("Failure to to parse response body: %s", err)
-				return 0, err
-			}
-			for _, d := range blk.Items {
-				// 201 코드 이상의 경우
-				if d.Index.Status > 201 {
-					// ... increment the error counter ...
-					//
-					// ... and print the response status and error information ...
-					logger.Errorf("Error: [%d]: %s: %s: %s: %s",
-						d.Index.Status,
-						d.Index.Error.Type,
-						d.Index.Error.Reason,
-						d.Index.Error.Cause.Type,
-						d.Index.Error.Cause.Reason,
-					)
-					// 201 코드 이하의 경우 성공 처리
-				} else {
-					logger.Debugf("Success: ID[%s] Result[%s] Status[%d] ",
-						d.Index.ID,
-						d.Index.Result,
-						d.Index.Status)
-					numIndexed++
-				}
-			}
-			//prometheus metrics counter
-			esWriteTotal.Add(float64(numIndexed))
-			return numIndexed, nil
-			// 응답에 에러가 있는 경우
-		} else {
-			var bodyObj jsonObj
-			if err := json.NewDecoder(res.Body).Decode(&bodyObj); err != nil {
-				logger.Errorf("Failure to to parse response body: %s", err)
-			} else {
-				logger.Printf("Error: [%d] %s: %s",
-					res.StatusCode,
-					bodyObj["error"].(jsonObj)["type"],
-					bodyObj["error"].(jsonObj)["reason"],
-				)
-			}
-			return numErrors, nil
+		result := blk.Items[i].Result()
+		switch {
+		// 2xx 코드나 이미 생성, 삭제된 문서의 경우 성공 처리
+		case result.Status < 300 || spes.Ignorable(item.op, result.Status):
+			logger.Debugf("Success: ID[%s] Result[%s] Status[%d] ",
+				result.ID,
+				result.Result,
+				result.Status)
+			item.ack.Done()
+			indexed++
+		// 429, 5xx 코드의 경우 재시도
+		case spes.Retryable(result.Status):
+			err = result.Err()
+			retry = append(retry, item)
+		// 그 밖의 코드는 영구 실패
+		default:
+			err = result.Err()
+			logger.Errorf("Error: %s: %s: %s", err, result.Error.Cause.Type, result.Error.Cause.Reason)
+			item.ack.Fail(err)
+			failed++
 		}
 	}
+	return retry, indexed, failed, err
+}
+
+// failItems reports a failed write of every document.
+func failItems(items []bulkItem, err error) {
+	for _, item := range items {
+		item.ack.Fail(err)
+	}
 }
@@ -0,0 +1,44 @@
+package es
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ERR_RESOURCE_ALREADY_EXISTS is the error type of creating an index or a
+// data stream that exists.
+const ERR_RESOURCE_ALREADY_EXISTS = "resource_already_exists_exception"
+
+// BootstrapCfg lists the files of the ILM policies, component templates and
+// index templates to create or update, by name. They are applied in this
+// order, so index templates can be composed of the component templates and
+// use the policies.
+//
+//	bootstrap:
+//	  ilm_policies:
+//	    event-data: elasticsearch/ilm/event-data.json
+//	  component_templates:
+//	    event-data-mappings: elasticsearch/component_templates/event-data-mappings.json
+//	  index_templates:
+//	    event-data: elasticsearch/index_templates/event-data.json
+type BootstrapCfg struct {
+	ILMPolicies        map[string]string `json:"ilm_policies,omitempty"`
+	ComponentTemplates map[string]string `json:"component_templates,omitempty"`
+	IndexTemplates     map[string]string `json:"index_templates,omitempty"`
+}
+
+// SortedNames returns the names of the files in alphabetical order.
+func SortedNames(files map[string]string) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FirstRolloverIndex returns the name of the first index of a rollover alias.
+// Rollovers increment its numeric suffix.
+func FirstRolloverIndex(alias string) string {
+	return fmt.Sprintf("%s-000001", alias)
+}
@@ -0,0 +1,141 @@
+package es
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// 벌크 액션
+const (
+	OP_INDEX  = "index"
+	OP_CREATE = "create"
+	OP_UPDATE = "update"
+	OP_DELETE = "delete"
+)
+
+// 페이로드 메타데이터에서 문서별로 설정을 덮어쓰는 키
+const (
+	META_OP_TYPE  = "op_type"
+	META_ROUTING  = "routing"
+	META_PIPELINE = "pipeline"
+)
+
+// Script is a painless script run by scripted updates.
+type Script struct {
+	Source string                 `json:"source"`
+	Lang   string                 `json:"lang,omitempty"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+// Op describes how a document is written in a bulk request.
+//
+//   - index writes the document, replacing an existing one.
+//   - create writes the document unless one with the same id exists, so
+//     writing it again is a no-op.
+//   - update merges the document into the existing one, creating it with
+//     DocAsUpsert. With a Script, the script updates the existing document
+//     and the document is inserted when there is none. The script gets the
+//     document as params.doc.
+//   - delete deletes the document, the payload data is not used.
+//
+// Routing and Pipeline set the shard routing and the ingest pipeline of the
+// document. The ingest pipeline only applies to index and create.
+type Op struct {
+	Type        string  `json:"op_type,omitempty"`
+	Routing     string  `json:"routing,omitempty"`
+	Pipeline    string  `json:"pipeline,omitempty"`
+	DocAsUpsert bool    `json:"doc_as_upsert,omitempty"`
+	Script      *Script `json:"script,omitempty"`
+}
+
+// Validate returns an error if the operation is not supported.
+func (o Op) Validate() error {
+	switch o.Type {
+	case OP_INDEX, OP_CREATE, OP_UPDATE, OP_DELETE:
+	default:
+		return fmt.Errorf("invalid op_type %q, must be one of %s, %s, %s, %s", o.Type, OP_INDEX, OP_CREATE, OP_UPDATE, OP_DELETE)
+	}
+	if o.Script != nil && o.Script.Source == "" {
+		return errors.New("script source is required")
+	}
+	return nil
+}
+
+// WithMetadata returns the operation with the op_type, routing and pipeline
+// of the payload metadata, when they are set.
+func (o Op) WithMetadata(metadata map[string]interface{}) (Op, error) {
+	for key, field := range map[string]*string{
+		META_OP_TYPE:  &o.Type,
+		META_ROUTING:  &o.Routing,
+		META_PIPELINE: &o.Pipeline,
+	} {
+		v, ok := metadata[key]
+		if !ok || v == nil {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			return o, fmt.Errorf("metadata %s: expected a string, got %T", key, v)
+		}
+		*field = s
+	}
+	return o, o.Validate()
+}
+
+// Lines returns the action line and, but for delete, the source line of the
+// document, each followed by a newline.
+func (o Op) Lines(index, id string, data []byte) ([]byte, error) {
+	meta := ActionMeta{Index: index, ID: id, Routing: o.Routing}
+	if o.Type == OP_INDEX || o.Type == OP_CREATE {
+		meta.Pipeline = o.Pipeline
+	}
+	action, err := json.Marshal(Action{o.Type: meta})
+	if err != nil {
+		return nil, err
+	}
+	lines := append(action, '\n')
+	if o.Type == OP_DELETE {
+		return lines, nil
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%s requires a document", o.Type)
+	}
+	source := data
+	if o.Type == OP_UPDATE {
+		if source, err = o.updateSource(data); err != nil {
+			return nil, err
+		}
+	}
+	lines = append(lines, source...)
+	return append(lines, '\n'), nil
+}
+
+// updateSource returns the source line of an update of the document.
+func (o Op) updateSource(data []byte) ([]byte, error) {
+	doc := json.RawMessage(data)
+	if o.Script == nil {
+		return json.Marshal(map[string]interface{}{
+			"doc":           doc,
+			"doc_as_upsert": o.DocAsUpsert,
+		})
+	}
+	params := make(map[string]interface{}, len(o.Script.Params)+1)
+	for k, v := range o.Script.Params {
+		params[k] = v
+	}
+	params["doc"] = doc
+	return json.Marshal(map[string]interface{}{
+		"script": Script{Source: o.Script.Source, Lang: o.Script.Lang, Params: params},
+		"upsert": doc,
+	})
+}
+
+// Ignorable tells whether an action failing with the status reached its goal
+// anyway: creating a document that exists or deleting one that does not.
+func Ignorable(op string, status int) bool {
+	return (op == OP_CREATE && status == http.StatusConflict) ||
+		(op == OP_DELETE && status == http.StatusNotFound)
+}
@@ -1,26 +1,112 @@
 package es
 
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
 const (
 	REMOTE_SERVICE_ES    = "ElasticSearch"
 	TICKER_TIMEOUT_MS    = 30000
 	RECORD_CNT_THRESHOLD = 1000
+	BYTES_THRESHOLD      = 5 << 20
+	RETRY_BACKOFF_MS     = 100
+	MAX_RETRY_BACKOFF_MS = 30000
+	BOOTSTRAP_TIMEOUT_MS = 120000
+	CLOSE_TIMEOUT_MS     = 30000
 )
 
+// 벌크 쓰기를 일으킨 플러시 조건
+const (
+	FLUSH_TRIGGER_DOCS     = "docs"
+	FLUSH_TRIGGER_BYTES    = "bytes"
+	FLUSH_TRIGGER_INTERVAL = "interval"
+	FLUSH_TRIGGER_CLOSE    = "close"
+)
+
+// Action is the action line of a document in a bulk request, the name of the
+// action mapped to its metadata.
+type Action map[string]ActionMeta
+
+// ActionMeta is the metadata of a bulk action.
+type ActionMeta struct {
+	Index    string `json:"_index"`
+	ID       string `json:"_id,omitempty"`
+	Routing  string `json:"routing,omitempty"`
+	Pipeline string `json:"pipeline,omitempty"`
+}
+
 type BulkResponse struct {
 	Errors bool `json:"errors"`
-	Items  []struct {
-		Index struct {
-			ID     string `json:"_id"`
-			Result string `json:"result"`
-			Status int    `json:"status"`
-			Error  struct {
-				Type   string `json:"type"`
-				Reason string `json:"reason"`
-				Cause  struct {
-					Type   string `json:"type"`
-					Reason string `json:"reason"`
-				} `json:"caused_by"`
-			} `json:"error"`
-		} `json:"index"`
-	} `json:"items"`
+	// 문서마다 액션 이름과 그 결과
+	Items []BulkResponseItem `json:"items"`
+}
+
+// BulkResponseItem maps the action of a document, e.g. index, to its result.
+type BulkResponseItem map[string]BulkItem
+
+// BulkItem is the result of one action of a bulk request.
+type BulkItem struct {
+	ID     string `json:"_id"`
+	Result string `json:"result"`
+	Status int    `json:"status"`
+	Error  Error  `json:"error"`
+}
+
+// Error is an error reported by elasticsearch.
+type Error struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+	Cause  struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"caused_by"`
+}
+
+// ErrorResponse is the body of a failed request.
+type ErrorResponse struct {
+	Error  Error `json:"error"`
+	Status int   `json:"status"`
+}
+
+// Err returns the error of the response.
+func (r ErrorResponse) Err() error {
+	return fmt.Errorf("[%d] %s: %s", r.Status, r.Error.Type, r.Error.Reason)
+}
+
+// Result returns the result of the action, whatever its name.
+func (i BulkResponseItem) Result() BulkItem {
+	for _, r := range i {
+		return r
+	}
+	return BulkItem{}
+}
+
+// Err returns the error of a failed action.
+func (r BulkItem) Err() error {
+	return fmt.Errorf("[%d] %s: %s", r.Status, r.Error.Type, r.Error.Reason)
+}
+
+// Retryable tells whether a request or an action failing with the status may
+// succeed when retried.
+func Retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// Backoff returns how long to wait before the attempt-th retry. It starts
+// from delay seconds, or RETRY_BACKOFF_MS when delay is not set, doubles on
+// every retry and is capped at MAX_RETRY_BACKOFF_MS.
+func Backoff(delay, attempt int) time.Duration {
+	backoff := time.Duration(delay) * time.Second
+	if backoff <= 0 {
+		backoff = RETRY_BACKOFF_MS * time.Millisecond
+	}
+	for i := 1; i < attempt && backoff < MAX_RETRY_BACKOFF_MS*time.Millisecond; i++ {
+		backoff *= 2
+	}
+	if backoff > MAX_RETRY_BACKOFF_MS*time.Millisecond {
+		backoff = MAX_RETRY_BACKOFF_MS * time.Millisecond
+	}
+	return backoff
 }
@@ -0,0 +1,160 @@
+package storage_providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"event-data-pipeline/pkg/logger"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	spes "event-data-pipeline/pkg/storage_providers/es"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// bootstrap creates or updates the ILM policies and templates of the
+// configuration, then the rollover alias or the data stream documents are
+// written to. Every step is idempotent, so each storage can run it at start.
+// Requests failing with a transport error or a retryable status are retried
+// for up to timeout, so the storage can start along with the cluster.
+func (e *ElasticSearchClient) bootstrap(cfg spes.BootstrapCfg, rolloverAlias, dataStream string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	steps := []struct {
+		kind  string
+		files map[string]string
+		put   func(name string, body io.Reader) (*esapi.Response, error)
+	}{
+		{"ilm policy", cfg.ILMPolicies, func(name string, body io.Reader) (*esapi.Response, error) {
+			return e.client.ILM.PutLifecycle(name, e.client.ILM.PutLifecycle.WithBody(body))
+		}},
+		{"component template", cfg.ComponentTemplates, func(name string, body io.Reader) (*esapi.Response, error) {
+			return e.client.Cluster.PutComponentTemplate(name, body)
+		}},
+		{"index template", cfg.IndexTemplates, func(name string, body io.Reader) (*esapi.Response, error) {
+			return e.client.Indices.PutIndexTemplate(name, body)
+		}},
+	}
+	for _, step := range steps {
+		for _, name := range spes.SortedNames(step.files) {
+			body, err := os.ReadFile(step.files[name])
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", step.kind, name, err)
+			}
+			put := step.put
+			_, errRes, err := e.do(deadline, func() (*esapi.Response, error) {
+				return put(name, bytes.NewReader(body))
+			})
+			if err == nil && errRes != nil {
+				err = errRes.Err()
+			}
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", step.kind, name, err)
+			}
+			logger.Infof("elasticsearch %s %s is up to date", step.kind, name)
+		}
+	}
+
+	switch {
+	case rolloverAlias != "":
+		return e.bootstrapRolloverAlias(rolloverAlias, deadline)
+	case dataStream != "":
+		return e.bootstrapDataStream(dataStream, deadline)
+	}
+	return nil
+}
+
+// bootstrapRolloverAlias creates the first index of the alias, as its write
+// index, unless the alias exists.
+func (e *ElasticSearchClient) bootstrapRolloverAlias(alias string, deadline time.Time) error {
+	status, _, err := e.do(deadline, func() (*esapi.Response, error) {
+		return e.client.Indices.ExistsAlias([]string{alias})
+	})
+	if err != nil {
+		return fmt.Errorf("rollover alias %s: %w", alias, err)
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+
+	index := spes.FirstRolloverIndex(alias)
+	body, _ := json.Marshal(jsonObj{"aliases": jsonObj{alias: jsonObj{"is_write_index": true}}})
+	_, errRes, err := e.do(deadline, func() (*esapi.Response, error) {
+		return e.client.Indices.Create(index, e.client.Indices.Create.WithBody(bytes.NewReader(body)))
+	})
+	// 다른 인스턴스가 먼저 만든 경우
+	if err == nil && errRes != nil && errRes.Error.Type != spes.ERR_RESOURCE_ALREADY_EXISTS {
+		err = errRes.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("rollover alias %s: %w", alias, err)
+	}
+	logger.Infof("elasticsearch rollover alias %s writes to %s", alias, index)
+	return nil
+}
+
+// bootstrapDataStream creates the data stream unless it exists. A matching
+// index template with data_stream enabled must exist.
+func (e *ElasticSearchClient) bootstrapDataStream(name string, deadline time.Time) error {
+	status, _, err := e.do(deadline, func() (*esapi.Response, error) {
+		return e.client.Indices.GetDataStream(e.client.Indices.GetDataStream.WithName(name))
+	})
+	if err != nil {
+		return fmt.Errorf("data stream %s: %w", name, err)
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+
+	_, errRes, err := e.do(deadline, func() (*esapi.Response, error) {
+		return e.client.Indices.CreateDataStream(name)
+	})
+	if err == nil && errRes != nil && errRes.Error.Type != spes.ERR_RESOURCE_ALREADY_EXISTS {
+		err = errRes.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("data stream %s: %w", name, err)
+	}
+	logger.Infof("elasticsearch data stream %s created", name)
+	return nil
+}
+
+// do performs a bootstrap request, retrying transport errors and retryable
+// statuses with a backoff until the deadline. It returns the status of the
+// response, and its error when the status is not 2xx.
+func (e *ElasticSearchClient) do(deadline time.Time, req func() (*esapi.Response, error)) (int, *spes.ErrorResponse, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			// 마감 시간을 넘겨 기다리지 않는다.
+			backoff := spes.Backoff(e.delay, attempt)
+			if remaining := time.Until(deadline); backoff > remaining {
+				backoff = remaining
+			}
+			logger.Infof("retrying[%d] in %v", attempt, backoff)
+			time.Sleep(backoff)
+		}
+
+		res, err := req()
+		if err == nil && spes.Retryable(res.StatusCode) {
+			err = fmt.Errorf("request failed: %s", res.Status())
+			res.Body.Close()
+		}
+		if err != nil {
+			logger.Errorf("error in elasticsearch request : %s", err.Error())
+			if !time.Now().Before(deadline) {
+				return 0, nil, fmt.Errorf("retry[%d] exceeded bootstrap timeout: %w", attempt, err)
+			}
+			continue
+		}
+
+		defer res.Body.Close()
+		if !res.IsError() {
+			return res.StatusCode, nil, nil
+		}
+		errRes := &spes.ErrorResponse{Status: res.StatusCode}
+		json.NewDecoder(res.Body).Decode(errRes)
+		return res.StatusCode, errRes, nil
+	}
+}
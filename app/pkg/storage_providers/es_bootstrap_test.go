@@ -0,0 +1,197 @@
+package storage_providers_test
+
+import (
+	"encoding/json"
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/storage_providers"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	gc "gopkg.in/check.v1"
+)
+
+// go test -check.f ESBootstrapSuite
+type ESBootstrapSuite struct {
+	esFixture
+	cluster *fakeCluster
+}
+
+var _ = gc.Suite(&ESBootstrapSuite{})
+
+func (s *ESBootstrapSuite) SetUpTest(c *gc.C) {
+	s.cluster = &fakeCluster{
+		bulk:        &fakeBulk{},
+		aliases:     map[string]bool{},
+		dataStreams: map[string]bool{},
+	}
+	s.serve(s.cluster.bulk, s.cluster)
+}
+
+func (s *ESBootstrapSuite) newClient(c *gc.C, cfg jsonObj) storage_providers.StorageProvider {
+	cfg["flush_max_docs"] = 1
+	cfg["flush_interval_ms"] = 60000
+	return s.esFixture.newClient(c, cfg)
+}
+
+func (s *ESBootstrapSuite) TestRolloverAlias(c *gc.C) {
+	cfg := func() jsonObj {
+		return jsonObj{
+			"bootstrap": jsonObj{
+				"ilm_policies": jsonObj{"event-data": "../../elasticsearch/ilm/event-data.json"},
+				"component_templates": jsonObj{
+					"event-data-settings": "../../elasticsearch/component_templates/event-data-settings.json",
+					"event-data-mappings": "../../elasticsearch/component_templates/event-data-mappings.json",
+				},
+				"index_templates": jsonObj{"event-data": "../../elasticsearch/index_templates/event-data.json"},
+			},
+			"rollover_alias": "event-data",
+		}
+	}
+	puts := []string{
+		"PUT /_ilm/policy/event-data",
+		"PUT /_component_template/event-data-mappings",
+		"PUT /_component_template/event-data-settings",
+		"PUT /_index_template/event-data",
+	}
+
+	es := s.newClient(c, cfg())
+	defer es.(io.Closer).Close()
+	c.Assert(s.cluster.takeRequests(), gc.DeepEquals, append(puts, "HEAD /_alias/event-data", "PUT /event-data-000001"))
+	c.Assert(s.cluster.aliases["event-data"], gc.Equals, true)
+
+	// 페이로드 인덱스 대신 별칭에 쓴다.
+	written, err := es.Write(&payloads.Event{Index: "event-data-12-31-2022", DocID: "doc", Data: []byte(`{"id":1}`)})
+	c.Assert(err, gc.IsNil)
+	c.Assert(written, gc.Equals, 1)
+	c.Assert(s.cluster.bulk.indices, gc.DeepEquals, []string{"event-data"})
+	s.cluster.takeRequests()
+
+	// 다시 시작해도 템플릿만 갱신하고 별칭은 그대로 둔다.
+	again := s.newClient(c, cfg())
+	defer again.(io.Closer).Close()
+	c.Assert(s.cluster.takeRequests(), gc.DeepEquals, append(puts, "HEAD /_alias/event-data"))
+}
+
+func (s *ESBootstrapSuite) TestRolloverAliasCreatedConcurrently(c *gc.C) {
+	// 별칭 확인 후 다른 인스턴스가 먼저 첫 인덱스를 만든 경우
+	s.cluster.indexExists = true
+	es := s.newClient(c, jsonObj{"rollover_alias": "event-data"})
+	defer es.(io.Closer).Close()
+	c.Assert(s.cluster.takeRequests(), gc.DeepEquals, []string{"HEAD /_alias/event-data", "PUT /event-data-000001"})
+}
+
+func (s *ESBootstrapSuite) TestDataStream(c *gc.C) {
+	es := s.newClient(c, jsonObj{"data_stream": "logs-edp"})
+	defer es.(io.Closer).Close()
+	c.Assert(s.cluster.takeRequests(), gc.DeepEquals, []string{"GET /_data_stream/logs-edp", "PUT /_data_stream/logs-edp"})
+	c.Assert(s.cluster.dataStreams["logs-edp"], gc.Equals, true)
+
+	// 데이터 스트림에는 create 로 쓴다.
+	written, err := es.Write(&payloads.Event{Index: "event-data-12-31-2022", DocID: "doc", Data: []byte(`{"@timestamp":"2022-12-31T00:00:00Z"}`)})
+	c.Assert(err, gc.IsNil)
+	c.Assert(written, gc.Equals, 1)
+	c.Assert(s.cluster.bulk.lines[0], gc.Equals, `{"create":{"_index":"logs-edp","_id":"doc"}}`)
+
+	again := s.newClient(c, jsonObj{"data_stream": "logs-edp"})
+	defer again.(io.Closer).Close()
+	c.Assert(s.cluster.takeRequests(), gc.DeepEquals, []string{"GET /_data_stream/logs-edp"})
+}
+
+func (s *ESBootstrapSuite) TestRetriesUntilTimeout(c *gc.C) {
+	// 부트스트랩은 벌크 쓰기의 max_retries 를 넘어서도 재시도한다.
+	s.cluster.unavailable = 3
+	es := s.newClient(c, jsonObj{"data_stream": "logs-edp", "max_retries": 1, "bootstrap_timeout_ms": 10000})
+	defer es.(io.Closer).Close()
+	c.Assert(s.cluster.takeRequests(), gc.HasLen, 5)
+	c.Assert(s.cluster.dataStreams["logs-edp"], gc.Equals, true)
+}
+
+// fakeCluster is an elasticsearch cluster answering the bootstrap requests
+// of the storage. Bulk requests go to bulk.
+type fakeCluster struct {
+	bulk *fakeBulk
+
+	mu       sync.Mutex
+	requests []string
+
+	aliases     map[string]bool
+	dataStreams map[string]bool
+	// 인덱스 생성 요청에 이미 있다고 응답한다.
+	indexExists bool
+	// 처음 unavailable 개의 요청에 429 로 응답한다.
+	unavailable int
+}
+
+func (f *fakeCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/" || strings.HasSuffix(r.URL.Path, "/_bulk") {
+		f.bulk.ServeHTTP(w, r)
+		return
+	}
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	w.Header().Set("Content-Type", "application/json")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	if f.unavailable > 0 {
+		f.unavailable--
+		writeError(w, http.StatusTooManyRequests, "es_rejected_execution_exception")
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	if len(body) > 0 && !json.Valid(body) {
+		writeError(w, http.StatusBadRequest, "parse_exception")
+		return
+	}
+
+	name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	switch {
+	case r.Method == http.MethodHead && strings.HasPrefix(r.URL.Path, "/_alias/"):
+		if !f.aliases[name] {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case strings.HasPrefix(r.URL.Path, "/_data_stream/"):
+		switch {
+		case r.Method == http.MethodGet && !f.dataStreams[name]:
+			writeError(w, http.StatusNotFound, "index_not_found_exception")
+		case r.Method == http.MethodPut && f.dataStreams[name]:
+			writeError(w, http.StatusBadRequest, "resource_already_exists_exception")
+		default:
+			f.dataStreams[name] = true
+			w.Write([]byte(`{"acknowledged":true}`))
+		}
+	case r.Method == http.MethodPut && !strings.HasPrefix(r.URL.Path, "/_"):
+		if f.indexExists {
+			writeError(w, http.StatusBadRequest, "resource_already_exists_exception")
+			return
+		}
+		var index struct {
+			Aliases map[string]interface{} `json:"aliases"`
+		}
+		json.Unmarshal(body, &index)
+		for alias := range index.Aliases {
+			f.aliases[alias] = true
+		}
+		w.Write([]byte(`{"acknowledged":true}`))
+	default:
+		w.Write([]byte(`{"acknowledged":true}`))
+	}
+}
+
+// takeRequests returns the requests received so far and forgets them.
+func (f *fakeCluster) takeRequests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	requests := f.requests
+	f.requests = nil
+	return requests
+}
+
+func writeError(w http.ResponseWriter, status int, errType string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(jsonObj{"status": status, "error": jsonObj{"type": errType, "reason": "fake"}})
+}
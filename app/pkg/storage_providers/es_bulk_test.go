@@ -0,0 +1,182 @@
+package storage_providers_test
+
+import (
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/storage_providers"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	gc "gopkg.in/check.v1"
+)
+
+// go test -check.f ESBulkSuite
+type ESBulkSuite struct {
+	esFixture
+}
+
+var _ = gc.Suite(&ESBulkSuite{})
+
+func (s *ESBulkSuite) TestMixedIndices(c *gc.C) {
+	indices := []string{
+		"event-data-12-31-2022",
+		"event-data-01-01-2023",
+		"normalized-purchases",
+		"event-data-12-31-2022",
+	}
+	es := s.newClient(c, jsonObj{
+		"flush_max_docs":    len(indices),
+		"flush_interval_ms": 60000,
+	})
+	defer es.(io.Closer).Close()
+
+	var written int
+	var err error
+	for i, index := range indices {
+		written, err = es.Write(&payloads.Event{
+			Index: index,
+			DocID: fmt.Sprintf("doc-%d", i),
+			Data:  []byte(`{"id":1}`),
+		})
+		c.Assert(err, gc.IsNil)
+	}
+
+	// 하나의 벌크 요청으로 문서마다 자신의 인덱스에 쓴다.
+	c.Assert(written, gc.Equals, len(indices))
+	c.Assert(s.bulk.paths, gc.DeepEquals, []string{"/_bulk"})
+	c.Assert(s.bulk.ids, gc.DeepEquals, []string{"doc-0", "doc-1", "doc-2", "doc-3"})
+	c.Assert(s.bulk.indices, gc.DeepEquals, indices)
+}
+
+func (s *ESBulkSuite) TestEscapedDocID(c *gc.C) {
+	es := s.newClient(c, jsonObj{
+		"flush_max_docs":    1,
+		"flush_interval_ms": 60000,
+	})
+	defer es.(io.Closer).Close()
+
+	written, err := es.Write(&payloads.Event{Index: "event-data", DocID: `say "hi"`, Data: []byte(`{"id":1}`)})
+	c.Assert(err, gc.IsNil)
+	c.Assert(written, gc.Equals, 1)
+	c.Assert(s.bulk.ids, gc.DeepEquals, []string{`say "hi"`})
+}
+
+func (s *ESBulkSuite) TestOpTypes(c *gc.C) {
+	testCases := []struct {
+		desc     string
+		cfg      jsonObj
+		metadata jsonObj
+		data     string
+		lines    []string
+	}{
+		{
+			desc:  "create with routing and pipeline",
+			cfg:   jsonObj{"op_type": "create", "routing": "user-1", "pipeline": "geoip"},
+			data:  `{"id":1}`,
+			lines: []string{`{"create":{"_index":"event-data","_id":"doc","routing":"user-1","pipeline":"geoip"}}`, `{"id":1}`},
+		},
+		{
+			desc:  "upsert",
+			cfg:   jsonObj{"op_type": "update", "doc_as_upsert": true, "pipeline": "geoip"},
+			data:  `{"id":1}`,
+			lines: []string{`{"update":{"_index":"event-data","_id":"doc"}}`, `{"doc":{"id":1},"doc_as_upsert":true}`},
+		},
+		{
+			desc: "scripted update",
+			cfg: jsonObj{"op_type": "update", "script": jsonObj{
+				"source": "ctx._source.count += params.doc.count",
+				"params": jsonObj{"step": 1},
+			}},
+			data: `{"count":1}`,
+			lines: []string{
+				`{"update":{"_index":"event-data","_id":"doc"}}`,
+				`{"script":{"source":"ctx._source.count += params.doc.count","params":{"doc":{"count":1},"step":1}},"upsert":{"count":1}}`,
+			},
+		},
+		{
+			desc:     "delete from metadata",
+			metadata: jsonObj{"op_type": "delete", "routing": "user-1"},
+			lines:    []string{`{"delete":{"_index":"event-data","_id":"doc","routing":"user-1"}}`},
+		},
+	}
+	for _, tC := range testCases {
+		c.Logf(tC.desc)
+		bulk := &fakeBulk{}
+		server := httptest.NewServer(bulk)
+
+		cfg := jsonObj{
+			"addresses":         []interface{}{server.URL},
+			"flush_max_docs":    1,
+			"flush_interval_ms": 60000,
+		}
+		for k, v := range tC.cfg {
+			cfg[k] = v
+		}
+		es, err := storage_providers.CreateStorageProvider("elasticsearch", cfg)
+		c.Assert(err, gc.IsNil)
+
+		written, err := es.Write(&payloads.Event{
+			Index:    "event-data",
+			DocID:    "doc",
+			Data:     []byte(tC.data),
+			Metadata: tC.metadata,
+		})
+		c.Assert(err, gc.IsNil)
+		c.Assert(written, gc.Equals, 1)
+		c.Assert(bulk.lines, gc.DeepEquals, tC.lines)
+
+		es.(io.Closer).Close()
+		server.Close()
+	}
+}
+
+func (s *ESBulkSuite) TestIgnorableFailures(c *gc.C) {
+	// 이미 있는 문서의 생성과 없는 문서의 삭제는 성공으로 처리한다.
+	s.bulk.status = func(req, doc int) int {
+		return []int{http.StatusConflict, http.StatusNotFound, http.StatusConflict}[doc]
+	}
+	es := s.newClient(c, jsonObj{
+		"op_type":           "create",
+		"flush_max_docs":    3,
+		"flush_interval_ms": 60000,
+	})
+	defer es.(io.Closer).Close()
+
+	acks := &ackRecorder{failed: map[string]error{}}
+	metadata := []jsonObj{nil, {"op_type": "delete"}, {"op_type": "index"}}
+	var written int
+	var err error
+	for i, md := range metadata {
+		id := fmt.Sprintf("doc-%d", i)
+		written, err = es.Write(&payloads.Event{
+			Index:    "event-data",
+			DocID:    id,
+			Data:     []byte(`{"id":1}`),
+			Metadata: md,
+			Ack:      acks.newAck(id),
+		})
+	}
+	c.Assert(err, gc.ErrorMatches, "1 of 3 documents failed: .*status_409.*")
+	c.Assert(written, gc.Equals, 2)
+	c.Assert(acks.done, gc.Equals, 2)
+	c.Assert(acks.failed["doc-2"], gc.NotNil)
+}
+
+func (s *ESBulkSuite) TestInvalidOpType(c *gc.C) {
+	es := s.newClient(c, jsonObj{
+		"flush_interval_ms": 60000,
+	})
+	defer es.(io.Closer).Close()
+
+	acks := &ackRecorder{failed: map[string]error{}}
+	_, err := es.Write(&payloads.Event{
+		Index:    "event-data",
+		DocID:    "doc",
+		Data:     []byte(`{"id":1}`),
+		Metadata: jsonObj{"op_type": "upsert"},
+		Ack:      acks.newAck("doc"),
+	})
+	c.Assert(err, gc.ErrorMatches, `invalid op_type "upsert".*`)
+	c.Assert(acks.failed["doc"], gc.NotNil)
+}
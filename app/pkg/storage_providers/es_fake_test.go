@@ -0,0 +1,134 @@
+package storage_providers_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"event-data-pipeline/pkg/storage_providers"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	gc "gopkg.in/check.v1"
+)
+
+// esFixture serves a fakeBulk for each test of the elasticsearch suites
+// embedding it.
+type esFixture struct {
+	server *httptest.Server
+	bulk   *fakeBulk
+}
+
+func (f *esFixture) SetUpTest(c *gc.C) {
+	f.serve(&fakeBulk{}, nil)
+}
+
+func (f *esFixture) TearDownTest(c *gc.C) {
+	f.server.Close()
+}
+
+// serve starts a server for bulk. Requests go to h, or to bulk when h is nil.
+func (f *esFixture) serve(bulk *fakeBulk, h http.Handler) {
+	if h == nil {
+		h = bulk
+	}
+	f.bulk = bulk
+	f.server = httptest.NewServer(h)
+}
+
+// newClient creates an elasticsearch storage writing to the server.
+func (f *esFixture) newClient(c *gc.C, cfg jsonObj) storage_providers.StorageProvider {
+	cfg["addresses"] = []interface{}{f.server.URL}
+	es, err := storage_providers.CreateStorageProvider("elasticsearch", cfg)
+	c.Assert(err, gc.IsNil)
+	return es
+}
+
+// fakeBulk is an elasticsearch bulk API. Documents are created unless
+// status or requestStatus say otherwise.
+type fakeBulk struct {
+	mu       sync.Mutex
+	requests int
+	// 요청 경로와 받은 줄
+	paths []string
+	lines []string
+	// 생성된 문서 ID 와 그 인덱스
+	ids     []string
+	indices []string
+
+	// req 번째 요청의 응답 코드, 없거나 0 이면 200
+	requestStatus func(req int) int
+	// req 번째 요청의 doc 번째 문서 결과 코드, 없거나 0 이면 201
+	status func(req, doc int) int
+}
+
+func (f *fakeBulk) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	w.Header().Set("Content-Type", "application/json")
+	if !strings.HasSuffix(r.URL.Path, "/_bulk") {
+		w.Write([]byte(`{"version":{"number":"8.2.0"}}`))
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	req := f.requests
+	f.requests++
+	f.paths = append(f.paths, r.URL.Path)
+
+	if f.requestStatus != nil {
+		if code := f.requestStatus(req); code != 0 && code != http.StatusOK {
+			w.WriteHeader(code)
+			json.NewEncoder(w).Encode(jsonObj{
+				"status": code,
+				"error":  jsonObj{"type": fmt.Sprintf("status_%d", code), "reason": "fake"},
+			})
+			return
+		}
+	}
+
+	// 액션 줄 다음에 delete 가 아니면 문서 줄이 온다.
+	var items []jsonObj
+	errors := false
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		f.lines = append(f.lines, scanner.Text())
+		var action map[string]jsonObj
+		json.Unmarshal(scanner.Bytes(), &action)
+		for name, meta := range action {
+			if name != "delete" && scanner.Scan() {
+				f.lines = append(f.lines, scanner.Text())
+			}
+			id, _ := meta["_id"].(string)
+			// 액션 줄에 인덱스가 없으면 경로의 인덱스
+			index, ok := meta["_index"].(string)
+			if !ok {
+				index = strings.Trim(strings.TrimSuffix(r.URL.Path, "_bulk"), "/")
+			}
+			code := http.StatusCreated
+			if f.status != nil {
+				if c := f.status(req, len(items)); c != 0 {
+					code = c
+				}
+			}
+			result := jsonObj{"_index": index, "_id": id, "status": code}
+			if code < 300 {
+				result["result"] = "created"
+				f.ids = append(f.ids, id)
+				f.indices = append(f.indices, index)
+			} else {
+				errors = true
+				result["error"] = jsonObj{"type": fmt.Sprintf("status_%d", code), "reason": "fake"}
+			}
+			items = append(items, jsonObj{name: result})
+		}
+	}
+	json.NewEncoder(w).Encode(jsonObj{"errors": errors, "items": items})
+}
+
+func (f *fakeBulk) docs() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.ids)
+}
@@ -0,0 +1,63 @@
+package storage_providers_test
+
+import (
+	"event-data-pipeline/pkg/storage_providers"
+	"fmt"
+	"io"
+	"time"
+
+	gc "gopkg.in/check.v1"
+)
+
+// go test -check.f ESFlushSuite
+type ESFlushSuite struct {
+	esFixture
+}
+
+var _ = gc.Suite(&ESFlushSuite{})
+
+func (s *ESFlushSuite) write(c *gc.C, es storage_providers.StorageProvider, n int) {
+	for i := 0; i < n; i++ {
+		payload := &esPayloadStub{"event-data-test", fmt.Sprintf("es.flush.test.%d", i), []byte(`{"id":1}`)}
+		_, err := es.Write(payload)
+		c.Assert(err, gc.IsNil)
+	}
+}
+
+func (s *ESFlushSuite) TestFlushMaxDocs(c *gc.C) {
+	es := s.newClient(c, jsonObj{"flush_max_docs": 2, "flush_interval_ms": 60000})
+	defer es.(io.Closer).Close()
+
+	s.write(c, es, 3)
+	c.Assert(s.bulk.docs(), gc.Equals, 2)
+}
+
+func (s *ESFlushSuite) TestFlushMaxBytes(c *gc.C) {
+	es := s.newClient(c, jsonObj{"flush_max_bytes": 100, "flush_interval_ms": 60000})
+	defer es.(io.Closer).Close()
+
+	// 액션 줄을 더해 문서 하나가 70 바이트 정도이므로 두번째 문서에서 플러시된다.
+	s.write(c, es, 3)
+	c.Assert(s.bulk.docs(), gc.Equals, 2)
+}
+
+func (s *ESFlushSuite) TestFlushInterval(c *gc.C) {
+	es := s.newClient(c, jsonObj{"flush_interval_ms": 20})
+	defer es.(io.Closer).Close()
+
+	s.write(c, es, 3)
+	deadline := time.Now().Add(5 * time.Second)
+	for s.bulk.docs() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(s.bulk.docs(), gc.Equals, 3)
+}
+
+func (s *ESFlushSuite) TestCloseFlushes(c *gc.C) {
+	es := s.newClient(c, jsonObj{"flush_interval_ms": 60000})
+
+	s.write(c, es, 3)
+	c.Assert(s.bulk.docs(), gc.Equals, 0)
+	c.Assert(es.(io.Closer).Close(), gc.IsNil)
+	c.Assert(s.bulk.docs(), gc.Equals, 3)
+}
@@ -0,0 +1,204 @@
+package storage_providers_test
+
+import (
+	"event-data-pipeline/pkg/payloads"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	gc "gopkg.in/check.v1"
+)
+
+// go test -check.f ESRetrySuite
+type ESRetrySuite struct {
+	esFixture
+}
+
+var _ = gc.Suite(&ESRetrySuite{})
+
+// write writes n documents in one bulk request and returns the result of the
+// write and the settled acks.
+func (s *ESRetrySuite) write(c *gc.C, n int, maxRetries int) (int, error, *ackRecorder) {
+	es := s.newClient(c, jsonObj{
+		"max_retries":       maxRetries,
+		"flush_max_docs":    n,
+		"flush_interval_ms": 60000,
+	})
+	defer es.(io.Closer).Close()
+
+	acks := &ackRecorder{failed: map[string]error{}}
+	var written int
+	var err error
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("doc-%d", i)
+		written, err = es.Write(&payloads.Event{
+			Index: "event-data-test",
+			DocID: id,
+			Data:  []byte(`{"id":1}`),
+			Ack:   acks.newAck(id),
+		})
+	}
+	return written, err, acks
+}
+
+func (s *ESRetrySuite) TestRetryableItems(c *gc.C) {
+	// 첫 요청에서 두번째 문서만 429 로 거절된다.
+	s.bulk.status = func(req, doc int) int {
+		if req == 0 && doc == 1 {
+			return http.StatusTooManyRequests
+		}
+		return 0
+	}
+	written, err, acks := s.write(c, 3, 3)
+	c.Assert(err, gc.IsNil)
+	c.Assert(written, gc.Equals, 3)
+	c.Assert(s.bulk.requests, gc.Equals, 2)
+	c.Assert(s.bulk.ids, gc.DeepEquals, []string{"doc-0", "doc-2", "doc-1"})
+	c.Assert(acks.done, gc.Equals, 3)
+	c.Assert(acks.failed, gc.HasLen, 0)
+}
+
+func (s *ESRetrySuite) TestRetryableRequest(c *gc.C) {
+	s.bulk.requestStatus = func(req int) int {
+		if req == 0 {
+			return http.StatusTooManyRequests
+		}
+		return 0
+	}
+	written, err, acks := s.write(c, 3, 3)
+	c.Assert(err, gc.IsNil)
+	c.Assert(written, gc.Equals, 3)
+	c.Assert(s.bulk.requests, gc.Equals, 2)
+	c.Assert(acks.done, gc.Equals, 3)
+}
+
+func (s *ESRetrySuite) TestPermanentFailure(c *gc.C) {
+	s.bulk.status = func(req, doc int) int {
+		if doc == 1 {
+			return http.StatusBadRequest
+		}
+		return 0
+	}
+	written, err, acks := s.write(c, 3, 3)
+	c.Assert(err, gc.ErrorMatches, "1 of 3 documents failed: .*status_400.*")
+	c.Assert(written, gc.Equals, 2)
+	c.Assert(s.bulk.requests, gc.Equals, 1)
+	c.Assert(acks.done, gc.Equals, 2)
+	c.Assert(acks.failed, gc.HasLen, 1)
+	c.Assert(acks.failed["doc-1"], gc.ErrorMatches, `\[400\] status_400: fake`)
+}
+
+func (s *ESRetrySuite) TestRetriesExceeded(c *gc.C) {
+	s.bulk.status = func(req, doc int) int {
+		return http.StatusTooManyRequests
+	}
+	written, err, acks := s.write(c, 2, 1)
+	c.Assert(err, gc.ErrorMatches, "2 of 2 documents failed: .*")
+	c.Assert(written, gc.Equals, 0)
+	c.Assert(s.bulk.requests, gc.Equals, 2)
+	c.Assert(acks.failed, gc.HasLen, 2)
+	c.Assert(strings.Contains(acks.failed["doc-0"].Error(), "exceeded max retries"), gc.Equals, true)
+}
+
+func (s *ESRetrySuite) TestCloseDuringBackoff(c *gc.C) {
+	s.bulk.status = func(req, doc int) int {
+		return http.StatusTooManyRequests
+	}
+	es := s.newClient(c, jsonObj{
+		"max_retries":       -1,
+		"delay":             60,
+		"flush_max_docs":    1,
+		"flush_interval_ms": 60000,
+	})
+
+	acks := &ackRecorder{failed: map[string]error{}}
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := es.Write(&payloads.Event{Index: "event-data-test", DocID: "doc-0", Data: []byte(`{"id":1}`), Ack: acks.newAck("doc-0")})
+		errCh <- err
+	}()
+	for s.bulkRequests() == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 재시도를 기다리는 중에 닫으면 기다리지 않고 남은 문서를 실패 처리한다.
+	start := time.Now()
+	c.Assert(es.(io.Closer).Close(), gc.IsNil)
+	select {
+	case err := <-errCh:
+		c.Assert(err, gc.ErrorMatches, "1 of 1 documents failed: .*")
+	case <-time.After(5 * time.Second):
+		c.Fatal("write did not stop retrying after close")
+	}
+	c.Assert(time.Since(start) < 5*time.Second, gc.Equals, true)
+	c.Assert(acks.failed["doc-0"], gc.ErrorMatches, ".*closed.*")
+}
+
+// closeWith buffers n documents and closes the storage.
+func (s *ESRetrySuite) closeWith(c *gc.C, n int, cfg jsonObj) (error, *ackRecorder) {
+	cfg["flush_interval_ms"] = 60000
+	es := s.newClient(c, cfg)
+
+	acks := &ackRecorder{failed: map[string]error{}}
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("doc-%d", i)
+		_, err := es.Write(&payloads.Event{Index: "event-data-test", DocID: id, Data: []byte(`{"id":1}`), Ack: acks.newAck(id)})
+		c.Assert(err, gc.IsNil)
+	}
+	return es.(io.Closer).Close(), acks
+}
+
+func (s *ESRetrySuite) TestCloseRetriesFinalFlush(c *gc.C) {
+	s.bulk.requestStatus = func(req int) int {
+		if req == 0 {
+			return http.StatusTooManyRequests
+		}
+		return http.StatusOK
+	}
+
+	// 닫을 때의 플러시는 done 이 닫혀도 재시도한다.
+	err, acks := s.closeWith(c, 3, jsonObj{"max_retries": 3})
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.bulkRequests(), gc.Equals, 2)
+	c.Assert(s.bulk.docs(), gc.Equals, 3)
+	c.Assert(acks.done, gc.Equals, 3)
+	c.Assert(acks.failed, gc.HasLen, 0)
+}
+
+func (s *ESRetrySuite) TestCloseTimeout(c *gc.C) {
+	s.bulk.requestStatus = func(req int) int {
+		return http.StatusTooManyRequests
+	}
+
+	// 재시도 횟수에 제한이 없어도 close_timeout_ms 가 지나면 남은 문서를 실패 처리한다.
+	start := time.Now()
+	err, acks := s.closeWith(c, 3, jsonObj{"max_retries": -1, "close_timeout_ms": 300})
+	c.Assert(time.Since(start) < 5*time.Second, gc.Equals, true)
+	c.Assert(err, gc.ErrorMatches, "3 of 3 documents failed: .*")
+	c.Assert(acks.done, gc.Equals, 0)
+	c.Assert(acks.failed, gc.HasLen, 3)
+	c.Assert(acks.failed["doc-0"], gc.ErrorMatches, ".*closed.*")
+}
+
+func (s *ESRetrySuite) bulkRequests() int {
+	s.bulk.mu.Lock()
+	defer s.bulk.mu.Unlock()
+	return s.bulk.requests
+}
+
+// ackRecorder records the acks settled by the storage.
+type ackRecorder struct {
+	mu     sync.Mutex
+	done   int
+	failed map[string]error
+}
+
+func (r *ackRecorder) newAck(id string) *payloads.Ack {
+	return payloads.NewAck(
+		func() { r.mu.Lock(); r.done++; r.mu.Unlock() },
+		func(err error) { r.mu.Lock(); r.failed[id] = err; r.mu.Unlock() },
+	)
+}
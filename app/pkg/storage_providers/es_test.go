@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"event-data-pipeline/pkg/logger"
 	"event-data-pipeline/pkg/payloads"
-	"event-data-pipeline/pkg/storage_providers"
 	"fmt"
+	"io"
 	"os"
 	"sync"
 
@@ -13,7 +13,9 @@ import (
 )
 
 // go test -check.f ESSuite
-type ESSuite struct{}
+type ESSuite struct {
+	esFixture
+}
 
 var _ = gc.Suite(&ESSuite{})
 
@@ -28,15 +30,8 @@ func (e *ESSuite) TearDownSuite(c *gc.C) {
 }
 
 func (f *ESSuite) TestWrite(c *gc.C) {
-	cfgObj := make(jsonObj)
-	addresses := &[]interface{}{"http://localhost:9200"}
-	cfgObj["addresses"] = addresses
-
 	// ES storage provider 인스턴스 생성
-	es, err := storage_providers.CreateStorageProvider("elasticsearch", cfgObj)
-
-	// 에러 체크
-	c.Assert(err, gc.IsNil)
+	es := f.newClient(c, make(jsonObj))
 
 	data := make(map[string]interface{})
 	data["id"] = 0
@@ -50,19 +45,15 @@ func (f *ESSuite) TestWrite(c *gc.C) {
 		c.Assert(err, gc.IsNil)
 	}
 
+	// 닫을 때 버퍼에 남은 문서까지 모두 쓴다.
+	c.Assert(es.(io.Closer).Close(), gc.IsNil)
+	c.Assert(f.bulk.docs(), gc.Equals, count)
 }
 
 func (f *ESSuite) TestConcurrentWrite(c *gc.C) {
 
-	cfgObj := make(jsonObj)
-	addresses := &[]interface{}{"http://localhost:9200"}
-	cfgObj["addresses"] = addresses
-
 	// ES storage provider 인스턴스 생성
-	es, err := storage_providers.CreateStorageProvider("elasticsearch", cfgObj)
-
-	// 에러 체크
-	c.Assert(err, gc.IsNil)
+	es := f.newClient(c, make(jsonObj))
 
 	// 동시 트린잭션 개수
 	requests := 1000
@@ -88,6 +79,9 @@ func (f *ESSuite) TestConcurrentWrite(c *gc.C) {
 	c.Logf("waiting...")
 	wg.Wait()
 	c.Logf("completed...")
+
+	c.Assert(es.(io.Closer).Close(), gc.IsNil)
+	c.Assert(f.bulk.docs(), gc.Equals, requests)
 }
 
 var _ payloads.Payload = new(esPayloadStub)
@@ -100,11 +100,13 @@ func (f *FilesystemClient) Write(payload interface{}) (int, error) { // 저장
 
 			// 성공적인 쓰기에 리턴.
 			if err == nil {
+				payloads.Acknowledge(payload)
 				return 1, nil
 			}
 
 			retry++
 			if limit >= 0 && retry >= limit {
+				payloads.Reject(payload, err)
 				return 0, err
 			}
 			time.Sleep(time.Duration(5) * time.Second)
@@ -164,7 +164,7 @@ func FilesystemWrite(num int, worker int, buffer int) {
 	fsClient, err := storage_providers.CreateStorageProvider("filesystem", fsCfg)
 
 	if err != nil {
-		fmt.Errorf(err.Error())
+		panic(err)
 	}
 	sink := fsClient.(pipelines.Sink)
 	for i := 0; i < num; i++ {
@@ -0,0 +1,148 @@
+package storage_providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"event-data-pipeline/pkg/concur"
+	"event-data-pipeline/pkg/kafka"
+	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/payloads"
+	"regexp"
+	"sync"
+)
+
+var _ StorageProvider = new(KafkaProducerClient)
+
+func init() {
+	Register("kafka", NewKafkaProducerClient)
+}
+
+// KafkaStorageCfg includes storage settings for kafka
+//
+// Payloads are written to Topic when it is set, otherwise to TopicPrefix
+// followed by the payload index, e.g. normalized. + purchases.
+type KafkaStorageCfg struct {
+	Topic           string  `json:"topic,omitempty"`
+	TopicPrefix     string  `json:"topic_prefix,omitempty"`
+	Worker          int     `json:"worker,omitempty"`
+	ProducerOptions jsonObj `json:"producer_options,omitempty"`
+}
+
+// 카프카 토픽 이름에 사용할 수 없는 문자
+var invalidTopicChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+type KafkaProducerClient struct {
+	producer    kafka.Producer
+	topic       string
+	topicPrefix string
+
+	workers *concur.WorkerPool
+	inCh    chan interface{}
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewKafkaProducerClient(config jsonObj) StorageProvider {
+	var ksc KafkaStorageCfg
+	// 바이트로 변환
+	cfgByte, _ := json.Marshal(config)
+
+	// 설정파일 Struct 으로 Load
+	json.Unmarshal(cfgByte, &ksc)
+
+	kfkPrdcrCfg := make(jsonObj)
+	kfkPrdcrCfg["producerOptions"] = ksc.ProducerOptions
+	producer := kafka.NewKafkaProducer(kfkPrdcrCfg)
+	err := producer.CreateProducer()
+	if err != nil {
+		logger.Fatalf("error in creating kafka producer: %s", err)
+	}
+
+	kc := &KafkaProducerClient{
+		producer:    producer,
+		topic:       ksc.Topic,
+		topicPrefix: ksc.TopicPrefix,
+		inCh:        make(chan interface{}),
+		done:        make(chan struct{}),
+	}
+
+	numWorkers := 1
+	if ksc.Worker > 0 {
+		numWorkers = ksc.Worker
+	}
+
+	kc.workers = concur.NewWorkerPool("kafka-workers", kc.inCh, numWorkers, kc.Write)
+	kc.workers.Start()
+
+	return kc
+}
+
+// Write queues the payload data to the topic derived from its index with the
+// document ID as message key. The payload is acknowledged by the delivery
+// report of the message.
+func (k *KafkaProducerClient) Write(payload interface{}) (int, error) {
+	if payload == nil {
+		return 0, errors.New("payload is nil")
+	}
+	index, docID, data := payload.(payloads.Payload).Out()
+	topic := TopicName(k.topic, k.topicPrefix, index)
+	if topic == "" || len(data) == 0 {
+		err := errors.New("payload has no topic or data")
+		payloads.Reject(payload, err)
+		return 0, err
+	}
+
+	var ack *payloads.Ack
+	if a, ok := payload.(payloads.Acknowledger); ok {
+		ack = a.GetAck()
+	}
+
+	var key []byte
+	if docID != "" {
+		key = []byte(docID)
+	}
+	err := k.producer.Produce(topic, key, data, ack)
+	if err != nil {
+		logger.Errorf("error in producing to %s: %v", topic, err)
+		payloads.Reject(payload, err)
+		return 0, err
+	}
+	return 1, nil
+}
+
+// Drain implements pipelines.Sink
+func (k *KafkaProducerClient) Drain(ctx context.Context, p payloads.Payload) error {
+	select {
+	case k.inCh <- p:
+		return nil
+	case <-k.done:
+		return errors.New("kafka storage is closed")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops the workers, once they are done with the messages they are
+// producing, and closes the producer after its queued messages are delivered.
+func (k *KafkaProducerClient) Close() error {
+	k.closeOnce.Do(func() {
+		close(k.done)
+		k.workers.Stop()
+		// 큐에 남은 메시지를 전송하고 전송 보고서를 받은 뒤 종료
+		k.producer.Close()
+	})
+	return nil
+}
+
+// TopicName returns the topic a payload with the given index is written to.
+func TopicName(topic, prefix, index string) string {
+	if topic != "" {
+		return topic
+	}
+	if index == "" {
+		return ""
+	}
+	return invalidTopicChars.ReplaceAllString(prefix+index, "_")
+}
@@ -0,0 +1,76 @@
+package storage_providers_test
+
+import (
+	"context"
+	"errors"
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/storage_providers"
+	"io"
+	"time"
+
+	gc "gopkg.in/check.v1"
+)
+
+// go test -check.f KafkaSuite
+type KafkaSuite struct{}
+
+var _ = gc.Suite(&KafkaSuite{})
+
+func (k *KafkaSuite) TestTopicName(c *gc.C) {
+	c.Assert(storage_providers.TopicName("events", "normalized.", "purchases"), gc.Equals, "events")
+	c.Assert(storage_providers.TopicName("", "normalized.", "purchases"), gc.Equals, "normalized.purchases")
+	c.Assert(storage_providers.TopicName("", "", "purchases/2022 01"), gc.Equals, "purchases_2022_01")
+	c.Assert(storage_providers.TopicName("", "normalized.", ""), gc.Equals, "")
+}
+
+func (k *KafkaSuite) TestWriteDeliveryFailure(c *gc.C) {
+	// 브로커에 연결할 수 없으면 전송 보고서를 통해 페이로드가 거절되어야 한다.
+	kafkaCfg := jsonObj{
+		"topic_prefix": "normalized.",
+		"producer_options": jsonObj{
+			"bootstrap.servers":  "127.0.0.1:1",
+			"message.timeout.ms": "500",
+		},
+	}
+	kafka, err := storage_providers.CreateStorageProvider("kafka", kafkaCfg)
+	c.Assert(err, gc.IsNil)
+
+	failed := make(chan error, 1)
+	ack := payloads.NewAck(func() { failed <- nil }, func(err error) { failed <- err })
+	payload := &payloads.KafkaPayload{Index: "purchases", DocID: "1", Data: []byte(`{}`), Ack: ack}
+
+	n, err := kafka.Write(payload)
+	c.Assert(err, gc.IsNil)
+	c.Assert(n, gc.Equals, 1)
+
+	select {
+	case err := <-failed:
+		c.Assert(err, gc.NotNil)
+	case <-time.After(10 * time.Second):
+		c.Fatal(errors.New("no delivery report"))
+	}
+}
+
+func (k *KafkaSuite) TestClose(c *gc.C) {
+	kafkaCfg := jsonObj{
+		"topic": "events",
+		"producer_options": jsonObj{
+			"bootstrap.servers": "127.0.0.1:1",
+		},
+	}
+	kafka, err := storage_providers.CreateStorageProvider("kafka", kafkaCfg)
+	c.Assert(err, gc.IsNil)
+
+	// 파이프라인은 싱크를 닫을 때 io.Closer 를 통해 프로듀서를 종료한다.
+	closer, ok := kafka.(io.Closer)
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(closer.Close(), gc.IsNil)
+	c.Assert(closer.Close(), gc.IsNil)
+
+	sink, ok := kafka.(interface {
+		Drain(context.Context, payloads.Payload) error
+	})
+	c.Assert(ok, gc.Equals, true)
+	err = sink.Drain(context.Background(), &payloads.KafkaPayload{Index: "purchases", Data: []byte(`{}`)})
+	c.Assert(err, gc.ErrorMatches, "kafka storage is closed")
+}
@@ -8,6 +8,15 @@ import (
 const (
 	EDP_ES_STORAGE_PROVIDER_WRITE_TOTAL      = "edp_es_storage_provider_write_total"
 	EDP_ES_STORAGE_PROVIDER_WRITE_TOTAL_HELP = "the number of messages that elasticsearch storage provider wrote in total"
+
+	EDP_ES_STORAGE_PROVIDER_WRITE_ERRORS_TOTAL      = "edp_es_storage_provider_write_errors_total"
+	EDP_ES_STORAGE_PROVIDER_WRITE_ERRORS_TOTAL_HELP = "the number of messages that elasticsearch storage provider failed to write in total"
+
+	EDP_ES_STORAGE_PROVIDER_RETRY_TOTAL      = "edp_es_storage_provider_retry_total"
+	EDP_ES_STORAGE_PROVIDER_RETRY_TOTAL_HELP = "the number of message writes that elasticsearch storage provider retried in total"
+
+	EDP_ES_STORAGE_PROVIDER_FLUSH_TOTAL      = "edp_es_storage_provider_flush_total"
+	EDP_ES_STORAGE_PROVIDER_FLUSH_TOTAL_HELP = "the number of bulk writes of the elasticsearch storage provider by flush trigger in total"
 )
 
 var (
@@ -15,8 +24,24 @@ var (
 		Name: EDP_ES_STORAGE_PROVIDER_WRITE_TOTAL,
 		Help: EDP_ES_STORAGE_PROVIDER_WRITE_TOTAL_HELP},
 	)
+	esWriteErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: EDP_ES_STORAGE_PROVIDER_WRITE_ERRORS_TOTAL,
+		Help: EDP_ES_STORAGE_PROVIDER_WRITE_ERRORS_TOTAL_HELP},
+	)
+	esRetryTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: EDP_ES_STORAGE_PROVIDER_RETRY_TOTAL,
+		Help: EDP_ES_STORAGE_PROVIDER_RETRY_TOTAL_HELP},
+	)
+	esFlushTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: EDP_ES_STORAGE_PROVIDER_FLUSH_TOTAL,
+		Help: EDP_ES_STORAGE_PROVIDER_FLUSH_TOTAL_HELP},
+		[]string{"trigger"},
+	)
 )
 
 func init() {
 	prometheus.Register(esWriteTotal)
+	prometheus.Register(esWriteErrorsTotal)
+	prometheus.Register(esRetryTotal)
+	prometheus.Register(esFlushTotal)
 }
@@ -0,0 +1,104 @@
+package storage_providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"event-data-pipeline/pkg/concur"
+	"event-data-pipeline/pkg/logger"
+	"event-data-pipeline/pkg/payloads"
+	"event-data-pipeline/pkg/rabbitmq"
+)
+
+var _ StorageProvider = new(RabbitMQProducerClient)
+
+func init() {
+	Register("rabbitmq", NewRabbitMQProducerClient)
+}
+
+// RabbitMQStorageCfg includes storage settings for rabbitmq
+//
+// Payloads are published to the exchange with RoutingKey, or with the
+// payload index when no routing key is configured.
+type RabbitMQStorageCfg struct {
+	Host             string `json:"host,omitempty"`
+	ExchangeName     string `json:"exchange_name,omitempty"`
+	ExchangeType     string `json:"exchange_type,omitempty"`
+	RoutingKey       string `json:"routing_key,omitempty"`
+	ConfirmTimeoutMs int    `json:"confirm_timeout_ms,omitempty"`
+	MaxRetries       int    `json:"max_retries,omitempty"`
+	Worker           int    `json:"worker,omitempty"`
+}
+
+type RabbitMQProducerClient struct {
+	producer   rabbitmq.Producer
+	routingKey string
+
+	workers *concur.WorkerPool
+	inCh    chan interface{}
+}
+
+func NewRabbitMQProducerClient(config jsonObj) StorageProvider {
+	var rsc RabbitMQStorageCfg
+	// 바이트로 변환
+	cfgByte, _ := json.Marshal(config)
+
+	// 설정파일 Struct 으로 Load
+	json.Unmarshal(cfgByte, &rsc)
+
+	rbbtmqPrdcrCfg := make(jsonObj)
+	rbbtmqPrdcrCfg["producerCfg"] = config
+	producer := rabbitmq.NewRabbitMQProducer(rbbtmqPrdcrCfg)
+	err := producer.CreateProducer()
+	if err != nil {
+		logger.Fatalf("error in creating rabbitmq producer: %s", err)
+	}
+
+	rc := &RabbitMQProducerClient{
+		producer:   producer,
+		routingKey: rsc.RoutingKey,
+		inCh:       make(chan interface{}),
+	}
+
+	numWorkers := 1
+	if rsc.Worker > 0 {
+		numWorkers = rsc.Worker
+	}
+
+	rc.workers = concur.NewWorkerPool("rabbitmq-workers", rc.inCh, numWorkers, rc.Write)
+	rc.workers.Start()
+
+	return rc
+}
+
+// Write publishes the payload data and acknowledges the payload once the
+// broker has confirmed it.
+func (r *RabbitMQProducerClient) Write(payload interface{}) (int, error) {
+	if payload == nil {
+		return 0, errors.New("payload is nil")
+	}
+	index, docID, data := payload.(payloads.Payload).Out()
+	if len(data) == 0 {
+		err := errors.New("payload has no data")
+		payloads.Reject(payload, err)
+		return 0, err
+	}
+
+	routingKey := r.routingKey
+	if routingKey == "" {
+		routingKey = index
+	}
+	err := r.producer.Publish(routingKey, docID, data)
+	if err != nil {
+		payloads.Reject(payload, err)
+		return 0, err
+	}
+	payloads.Acknowledge(payload)
+	return 1, nil
+}
+
+// Drain implements pipelines.Sink
+func (r *RabbitMQProducerClient) Drain(ctx context.Context, p payloads.Payload) error {
+	r.inCh <- p
+	return nil
+}